@@ -0,0 +1,288 @@
+package vc
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// archiveVersion is the version of the archive format written by export
+const archiveVersion = 1
+
+// Archive formats
+const (
+	archiveTar  = "tar"
+	archiveJSON = "json"
+	archiveYAML = "yaml"
+)
+
+const (
+	archiveManifest = "manifest"
+	archiveSecrets  = "secrets/"
+)
+
+// archive is a snapshot of a tree of secrets; secrets are keyed by their path
+// relative to Root
+type archive struct {
+	Version int
+	Root    string
+	Created time.Time
+	Secrets map[string]map[string]interface{}
+}
+
+// paths returns the sorted relative paths of the secrets in the archive
+func (a *archive) paths() []string {
+	paths := make([]string, 0, len(a.Secrets))
+	for name := range a.Secrets {
+		paths = append(paths, name)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// manifest returns the archive header as secret data
+func (a *archive) manifest() map[string]interface{} {
+	return map[string]interface{}{
+		"version": a.Version,
+		"root":    a.Root,
+		"created": a.Created.UTC().Format(time.RFC3339),
+	}
+}
+
+// parseManifest parses the archive header from decoded data
+func (a *archive) parseManifest(data map[string]interface{}) error {
+	if a.Version = toInt(data["version"]); a.Version < 1 {
+		return fmt.Errorf("vc: archive has no version")
+	} else if a.Version > archiveVersion {
+		return fmt.Errorf("vc: unsupported archive version %d", a.Version)
+	}
+	a.Root, _ = data["root"].(string)
+	a.Created = toTime(data["created"])
+	return nil
+}
+
+// archiveFormat guesses the archive format from a file name, defaults to JSON
+func archiveFormat(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".tar":
+		return archiveTar
+	case ".yaml", ".yml":
+		return archiveYAML
+	default:
+		return archiveJSON
+	}
+}
+
+// sniffArchiveFormat guesses the archive format from its contents; anything
+// that is not tar or JSON is assumed to be YAML, if YAML support is compiled
+// in. Returns an empty string if the format is not recognised.
+func sniffArchiveFormat(p []byte) string {
+	if len(p) > 262 && string(p[257:262]) == "ustar" {
+		return archiveTar
+	}
+	if trimmed := bytes.TrimSpace(p); len(trimmed) > 0 && trimmed[0] == '{' {
+		return archiveJSON
+	}
+	if _, err := CodecFor(archiveYAML); err == nil {
+		return archiveYAML
+	}
+	return ""
+}
+
+// writeArchive writes the archive in format; the archive is a single json or
+// yaml document, or a tar of documents with the document codec
+func writeArchive(w io.Writer, a *archive, format, document string) error {
+	if format != archiveTar {
+		c, err := CodecFor(format)
+		if err != nil {
+			return err
+		}
+		data := a.manifest()
+		secrets := make(map[string]interface{}, len(a.Secrets))
+		for name, secret := range a.Secrets {
+			secrets[name] = secret
+		}
+		data["secrets"] = secrets
+
+		b, err := c.Marshal(a.Root, data)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	}
+
+	c, err := CodecFor(document)
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(w)
+	add := func(name string, data map[string]interface{}) error {
+		b, err := c.Marshal(name, data)
+		if err != nil {
+			return err
+		}
+		if err = tw.WriteHeader(&tar.Header{
+			Name:    name,
+			Mode:    0600,
+			Size:    int64(len(b)),
+			ModTime: a.Created,
+		}); err != nil {
+			return err
+		}
+		_, err = tw.Write(b)
+		return err
+	}
+
+	if err = add(archiveManifest+"."+document, a.manifest()); err != nil {
+		return err
+	}
+	for _, name := range a.paths() {
+		if err = add(archiveSecrets+name+"."+document, a.Secrets[name]); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// readArchive reads an archive in format, or guesses the format if empty
+func readArchive(r io.Reader, format string) (*archive, error) {
+	p, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if format == "" {
+		if format = sniffArchiveFormat(p); format == "" {
+			return nil, fmt.Errorf("vc: unrecognised archive format")
+		}
+	}
+	Debugf("archive: reading %d bytes as %s", len(p), format)
+
+	a := &archive{Secrets: make(map[string]map[string]interface{})}
+	if format != archiveTar {
+		c, err := CodecFor(format)
+		if err != nil {
+			return nil, err
+		}
+		data, err := c.Unmarshal(p)
+		if err != nil {
+			return nil, err
+		}
+		if err = a.parseManifest(data); err != nil {
+			return nil, err
+		}
+		secrets, ok := normalizeData(data["secrets"]).(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("vc: archive has no secrets")
+		}
+		for name, value := range secrets {
+			secret, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("vc: archive secret %q is not a map", name)
+			}
+			if err = checkArchiveName(name); err != nil {
+				return nil, err
+			}
+			a.Secrets[name] = secret
+		}
+		return a, nil
+	}
+
+	var (
+		tr       = tar.NewReader(bytes.NewReader(p))
+		manifest bool
+	)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		ext := path.Ext(header.Name)
+		c, err := CodecFor(strings.TrimPrefix(ext, "."))
+		if err != nil {
+			return nil, fmt.Errorf("vc: archive member %s: %v", header.Name, err)
+		}
+		b, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		data, err := c.Unmarshal(b)
+		if err != nil {
+			return nil, fmt.Errorf("vc: archive member %s: %v", header.Name, err)
+		}
+		data = normalizeData(data).(map[string]interface{})
+
+		name := strings.TrimSuffix(header.Name, ext)
+		switch {
+		case name == archiveManifest:
+			if err = a.parseManifest(data); err != nil {
+				return nil, err
+			}
+			manifest = true
+		case strings.HasPrefix(name, archiveSecrets):
+			name = strings.TrimPrefix(name, archiveSecrets)
+			if err = checkArchiveName(name); err != nil {
+				return nil, err
+			}
+			a.Secrets[name] = data
+		default:
+			Debugf("archive: ignoring member %s", header.Name)
+		}
+	}
+	if !manifest {
+		return nil, fmt.Errorf("vc: archive has no manifest")
+	}
+	return a, nil
+}
+
+// checkArchiveName checks that the relative path of a secret in an archive
+// can not escape the root it is restored to
+func checkArchiveName(name string) error {
+	if name == "" || path.IsAbs(name) || name != path.Clean(name) || name == "." {
+		return fmt.Errorf("vc: archive secret %q: invalid path", name)
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return fmt.Errorf("vc: archive secret %q: path escapes the root", name)
+		}
+	}
+	return nil
+}
+
+// normalizeData converts maps decoded from YAML to maps with string keys
+func normalizeData(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			out[fmt.Sprint(key)] = normalizeData(value)
+		}
+		return out
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = normalizeData(value)
+		}
+		return v
+	case []interface{}:
+		for i, value := range v {
+			v[i] = normalizeData(value)
+		}
+		return v
+	default:
+		return v
+	}
+}
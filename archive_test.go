@@ -0,0 +1,185 @@
+package vc
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestArchive(t *testing.T) {
+	ReplaceCodec(archiveJSON, new(testCodec))
+
+	a := &archive{
+		Version: archiveVersion,
+		Root:    "/secret/team",
+		Created: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Secrets: map[string]map[string]interface{}{
+			"app/db": {"password": "test"},
+			"tls":    {CodecTypeKey: "file", "contents": "dGVzdA=="},
+		},
+	}
+
+	for _, format := range []string{archiveJSON, archiveTar} {
+		buf := new(bytes.Buffer)
+		if err := writeArchive(buf, a, format, archiveJSON); err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if sniffed := sniffArchiveFormat(buf.Bytes()); sniffed != format {
+			t.Fatalf("%s: sniffed format %q", format, sniffed)
+		}
+
+		b, err := readArchive(buf, "")
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if !reflect.DeepEqual(a, b) {
+			t.Fatalf("%s: expected %+v, got %+v", format, a, b)
+		}
+	}
+
+	if _, err := readArchive(bytes.NewBufferString(`{"version": 99, "secrets": {}}`), ""); err == nil {
+		t.Fatal("expected error for unsupported version")
+	}
+}
+
+func TestArchiveUnrecognised(t *testing.T) {
+	if _, err := CodecFor(archiveYAML); err == nil {
+		t.Skip("yaml support compiled in")
+	}
+
+	// A truncated tar has no magic, it must not be reported as YAML
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	tw.WriteHeader(&tar.Header{Name: archiveManifest + ".json", Mode: 0600, Size: 2})
+	tw.Write([]byte("{}"))
+	tw.Close()
+
+	for _, p := range [][]byte{[]byte("garbage"), buf.Bytes()[:200]} {
+		_, err := readArchive(bytes.NewReader(p), "")
+		if err == nil || err.Error() != "vc: unrecognised archive format" {
+			t.Fatalf("expected unrecognised archive format, got %v", err)
+		}
+	}
+	if format := sniffArchiveFormat(buf.Bytes()); format != archiveTar {
+		t.Fatalf("expected tar, got %q", format)
+	}
+}
+
+func TestArchiveTraversal(t *testing.T) {
+	ReplaceCodec(archiveJSON, new(testCodec))
+
+	for _, name := range []string{"../other/secret", "app/../../sys/x", "/sys/x", "app//db", "app/./db", "."} {
+		a := &archive{
+			Version: archiveVersion,
+			Root:    "/secret/team",
+			Secrets: map[string]map[string]interface{}{
+				name: {"password": "test"},
+			},
+		}
+		for _, format := range []string{archiveJSON, archiveTar} {
+			buf := new(bytes.Buffer)
+			if err := writeArchive(buf, a, format, archiveJSON); err != nil {
+				t.Fatalf("%s: %v", format, err)
+			}
+			if _, err := readArchive(buf, format); err == nil {
+				t.Fatalf("%s: expected error for secret %q", format, name)
+			}
+		}
+	}
+}
+
+func TestExportImportCommand(t *testing.T) {
+	ReplaceCodec(archiveJSON, new(testCodec))
+
+	dir, err := ioutil.TempDir("", "vc-test")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer os.RemoveAll(dir)
+
+	_, client := testMemoryClient()
+	testWrite(t, client, "secret/team/app/db", map[string]interface{}{"password": "test"})
+	testWrite(t, client, "secret/team/tls", map[string]interface{}{CodecTypeKey: "file", "contents": "dGVzdA=="})
+	testWrite(t, client, "kv/restore/tls", map[string]interface{}{"contents": "exists"})
+
+	archive := filepath.Join(dir, "team.tar")
+	traversal := filepath.Join(dir, "traversal.json")
+	if err = ioutil.WriteFile(traversal, []byte(`{"version": 1, "root": "/secret/team", "secrets": {"../../kv/escaped": {"password": "test"}}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []testCommand{
+		testCommand{
+			Factory: ExportCommandFactory,
+			Args:    []string{"--help"},
+			Code:    Success,
+		},
+		testCommand{
+			Factory: ExportCommandFactory,
+			Args:    []string{"-o", archive, "secret/team"},
+			Code:    Success,
+			Client:  client,
+		},
+		testCommand{
+			Factory: ExportCommandFactory,
+			Args:    []string{"-o", archive, "secret/missing"},
+			Code:    SyntaxError,
+			Client:  client,
+		},
+		testCommand{
+			Factory: ImportCommandFactory,
+			Args:    []string{"-n", archive, "kv/dry"},
+			Code:    Success,
+			Client:  client,
+		},
+		testCommand{
+			Factory: ImportCommandFactory,
+			Args:    []string{"-n", traversal},
+			Code:    CodecError,
+			Client:  client,
+		},
+		testCommand{
+			Factory: ImportCommandFactory,
+			Args:    []string{"-f", traversal},
+			Code:    CodecError,
+			Client:  client,
+		},
+		testCommand{
+			Factory: ImportCommandFactory,
+			Args:    []string{"-conflict", "fail", archive, "kv/restore"},
+			Code:    ServerError,
+			Client:  client,
+		},
+		testCommand{
+			Factory: ImportCommandFactory,
+			Args:    []string{"-conflict", "skip", archive, "kv/restore"},
+			Code:    Success,
+			Client:  client,
+		},
+	} {
+		testCommandRun(t, test)
+	}
+
+	if secret, err := client.Read("kv/dry/app/db"); err != nil || secret != nil {
+		t.Fatalf("expected no secret after dry run, got %+v (%v)", secret, err)
+	}
+	if secret, err := client.Read("kv/escaped"); err != nil || secret != nil {
+		t.Fatalf("expected no secret outside the root, got %+v (%v)", secret, err)
+	}
+	for path, want := range map[string]map[string]interface{}{
+		"kv/restore/app/db": {"password": "test"},
+		"kv/restore/tls":    {"contents": "exists"},
+	} {
+		secret, err := client.Read(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if secret == nil || !reflect.DeepEqual(secret.Data, want) {
+			t.Fatalf("%s: expected %+v, got %+v", path, want, secret)
+		}
+	}
+}
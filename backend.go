@@ -0,0 +1,71 @@
+package vc
+
+import (
+	"github.com/hashicorp/vault/api"
+)
+
+// Backend implements the raw storage operations of Vault that Client builds
+// upon. Paths are API paths, relative to the root and without the KV version 2
+// data/ and metadata/ rewriting done by Client.
+type Backend interface {
+	// Read a secret
+	Read(path string) (*api.Secret, error)
+
+	// ReadWithData reads a secret with request parameters
+	ReadWithData(path string, data map[string][]string) (*api.Secret, error)
+
+	// Write a secret
+	Write(path string, data map[string]interface{}) (*api.Secret, error)
+
+	// Delete a secret
+	Delete(path string) (*api.Secret, error)
+
+	// List secrets
+	List(path string) (*api.Secret, error)
+
+	// ListMounts lists the mounted secrets engines
+	ListMounts() (map[string]*api.MountOutput, error)
+
+	// LookupSelf looks up the token in use
+	LookupSelf() (*api.Secret, error)
+
+	// CapabilitiesSelf looks up the capabilities of the token in use on path
+	CapabilitiesSelf(path string) ([]string, error)
+}
+
+// apiBackend is a Backend for the Vault API
+type apiBackend struct {
+	*api.Client
+}
+
+func (b apiBackend) Read(path string) (*api.Secret, error) {
+	return b.Logical().Read(path)
+}
+
+func (b apiBackend) ReadWithData(path string, data map[string][]string) (*api.Secret, error) {
+	return b.Logical().ReadWithData(path, data)
+}
+
+func (b apiBackend) Write(path string, data map[string]interface{}) (*api.Secret, error) {
+	return b.Logical().Write(path, data)
+}
+
+func (b apiBackend) Delete(path string) (*api.Secret, error) {
+	return b.Logical().Delete(path)
+}
+
+func (b apiBackend) List(path string) (*api.Secret, error) {
+	return b.Logical().List(path)
+}
+
+func (b apiBackend) ListMounts() (map[string]*api.MountOutput, error) {
+	return b.Sys().ListMounts()
+}
+
+func (b apiBackend) LookupSelf() (*api.Secret, error) {
+	return b.Auth().Token().LookupSelf()
+}
+
+func (b apiBackend) CapabilitiesSelf(path string) ([]string, error) {
+	return b.Sys().CapabilitiesSelf(path)
+}
@@ -3,6 +3,7 @@ package vc
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -26,6 +27,11 @@ const (
 	ServerError
 	SystemError
 	CodecError
+
+	// UsageError is returned for syntax errors by commands that use return
+	// code 1 as a result, such as diff, grep and certs
+	UsageError
+
 	Help = cli.RunResultHelp
 )
 
@@ -62,6 +68,9 @@ type baseCommand struct {
 	group string
 	tmp   *os.File
 	w     io.WriteCloser
+
+	encrypt    bool
+	recipients string
 }
 
 func (cmd *baseCommand) Client() (*Client, error) {
@@ -104,6 +113,54 @@ func (cmd *baseCommand) Client() (*Client, error) {
 	return cmd.c, err
 }
 
+// globs expands one or more paths containing glob(s); globs without matches
+// are an error
+func (cmd *baseCommand) globs(c *Client, patterns []string) (expanded []string, err error) {
+	for _, pattern := range patterns {
+		if !c.isGlob(pattern) {
+			expanded = append(expanded, pattern)
+			continue
+		}
+
+		var infos []os.FileInfo
+		if infos, err = c.Glob(pattern); err != nil {
+			return
+		}
+		if len(infos) == 0 {
+			return nil, fmt.Errorf("%s: no matches", pattern)
+		}
+		for _, info := range infos {
+			expanded = append(expanded, info.Name())
+		}
+	}
+	return
+}
+
+// hasGlobs checks if any of the patterns contains a glob
+func (cmd *baseCommand) hasGlobs(c *Client, patterns []string) bool {
+	for _, pattern := range patterns {
+		if c.isGlob(pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// confirmExpansion shows the paths a glob expanded to for a destructive
+// action, and asks the user for confirmation unless force is enabled
+func (cmd *baseCommand) confirmExpansion(action string, paths []string, force bool) (bool, error) {
+	if force {
+		return true, nil
+	}
+	if !IsTerminal(os.Stdout.Fd()) {
+		return false, fmt.Errorf("refusing to %s %d paths matched by glob without force", action, len(paths))
+	}
+	for _, path := range paths {
+		cmd.ui.Output("  " + path)
+	}
+	return confirmf("%s %d paths?", action, len(paths)), nil
+}
+
 // Close the output file (if any) and rename it to cmd.out
 func (cmd *baseCommand) Close() error {
 	if cmd.w != nil && cmd.w != os.Stdout {
@@ -121,7 +178,14 @@ func (cmd *baseCommand) Close() error {
 
 // writerOpen opens a file in the directory of cmd.out; with the correct mode;
 // if the caller calls .Close(), the file gets renamed to cmd.out
-func (cmd *baseCommand) writerOpen() error {
+func (cmd *baseCommand) writerOpen() (err error) {
+	if err = cmd.writerOpenFile(); err == nil && cmd.w != nil {
+		cmd.w, err = cmd.sealed(cmd.w)
+	}
+	return
+}
+
+func (cmd *baseCommand) writerOpenFile() error {
 	if cmd.out == "" || cmd.out == "-" {
 		if cmd.w == nil {
 			cmd.w = os.Stdout
@@ -161,6 +225,94 @@ func (cmd *baseCommand) writerOpen() error {
 	return err
 }
 
+// encryptFlags adds the flags for encrypted output
+func (cmd *baseCommand) encryptFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&cmd.encrypt, "encrypt", false, "encrypt output with a passphrase (from $"+PassphraseEnv+" or prompted)")
+	fs.StringVar(&cmd.recipients, "recipient", "", "encrypt output to comma separated public key files")
+}
+
+// sealed wraps w to encrypt all output if encryption is enabled
+func (cmd *baseCommand) sealed(w io.WriteCloser) (io.WriteCloser, error) {
+	if cmd.recipients != "" {
+		var keys []*[sealKeySize]byte
+		for _, name := range strings.Split(cmd.recipients, ",") {
+			key, err := readSealKey(strings.TrimSpace(name))
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+		}
+		return &sealWriter{w: w, seal: func(p []byte) ([]byte, error) {
+			return sealKeys(p, keys)
+		}}, nil
+	}
+
+	if cmd.encrypt {
+		passphrase, err := cmd.passphrase(true)
+		if err != nil {
+			return nil, err
+		}
+		return &sealWriter{w: w, seal: func(p []byte) ([]byte, error) {
+			return sealPassphrase(p, passphrase)
+		}}, nil
+	}
+
+	return w, nil
+}
+
+// unseal decrypts sealed data with the private key file, or with a passphrase
+func (cmd *baseCommand) unseal(p []byte, keyFile string) ([]byte, error) {
+	mode, err := sealMode(p)
+	if err != nil {
+		return nil, err
+	}
+
+	if mode == sealModeKeys {
+		if keyFile == "" {
+			return nil, errors.New("data is encrypted with public keys, supply a private key")
+		}
+		key, err := readSealKey(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		return openKeys(p, key)
+	}
+
+	passphrase, err := cmd.passphrase(false)
+	if err != nil {
+		return nil, err
+	}
+	return openPassphrase(p, passphrase)
+}
+
+// passphrase for encryption, from the environment or prompted; if confirm is
+// enabled the user has to enter the passphrase twice
+func (cmd *baseCommand) passphrase(confirm bool) ([]byte, error) {
+	if passphrase := os.Getenv(PassphraseEnv); passphrase != "" {
+		Debugf("crypt: using passphrase from %s", PassphraseEnv)
+		return []byte(passphrase), nil
+	}
+	if !IsTerminal(os.Stdin.Fd()) {
+		return nil, fmt.Errorf("no passphrase, set %s", PassphraseEnv)
+	}
+
+	passphrase, err := cmd.ui.AskSecret("passphrase:")
+	if err != nil {
+		return nil, err
+	} else if passphrase == "" {
+		return nil, errors.New("empty passphrase")
+	}
+	if confirm {
+		again, err := cmd.ui.AskSecret("confirm passphrase:")
+		if err != nil {
+			return nil, err
+		} else if again != passphrase {
+			return nil, errors.New("passphrases do not match")
+		}
+	}
+	return []byte(passphrase), nil
+}
+
 func (cmd *baseCommand) getGroupId() (int, error) {
 	if cmd.group == "" {
 		return -1, nil
@@ -224,6 +376,18 @@ func (s *stringValue) Get() interface{} { return string(*s) }
 
 func (s *stringValue) String() string { return string(*s) }
 
+// stringsValue is a flag that can be given multiple times
+type stringsValue []string
+
+func (s *stringsValue) Set(val string) error {
+	*s = append(*s, val)
+	return nil
+}
+
+func (s *stringsValue) Get() interface{} { return []string(*s) }
+
+func (s *stringsValue) String() string { return strings.Join(*s, ",") }
+
 func defaults(fs *flag.FlagSet) string {
 	b := new(bytes.Buffer)
 	fs.VisitAll(func(f *flag.Flag) {
@@ -312,16 +476,33 @@ func confirmf(format string, v ...interface{}) bool {
 // DefaultCommands returns a map of default commands
 func DefaultCommands(ui cli.Ui) map[string]cli.CommandFactory {
 	return map[string]cli.CommandFactory{
-		"cat":      CatCommandFactory(ui),
-		"cp":       CopyCommandFactory(ui),
-		"edit":     EditCommandFactory(ui),
-		"file get": FileCommandFactory(ui, "get"),
-		"file put": FileCommandFactory(ui, "put"),
-		"ls":       ListCommandFactory(ui),
-		"mv":       MoveCommandFactory(ui),
-		"rm":       DeleteCommandFactory(ui),
-		"template": TemplateCommandFactory(ui),
-		"shell":    ShellCommandFactory(ui),
+		"cat":            CatCommandFactory(ui),
+		"certs":          CertsCommandFactory(ui),
+		"cp":             CopyCommandFactory(ui),
+		"decrypt":        DecryptCommandFactory(ui),
+		"destroy":        DestroyCommandFactory(ui),
+		"diff":           DiffCommandFactory(ui),
+		"edit":           EditCommandFactory(ui),
+		"exec":           ExecCommandFactory(ui),
+		"export":         ExportCommandFactory(ui),
+		"file get":       FileCommandFactory(ui, "get"),
+		"file put":       FileCommandFactory(ui, "put"),
+		"file sync push": SyncCommandFactory(ui, "push"),
+		"file sync pull": SyncCommandFactory(ui, "pull"),
+		"find":           FindCommandFactory(ui),
+		"gen":            GenCommandFactory(ui),
+		"grep":           GrepCommandFactory(ui),
+		"history":        HistoryCommandFactory(ui),
+		"import":         ImportCommandFactory(ui),
+		"ls":             ListCommandFactory(ui),
+		"mv":             MoveCommandFactory(ui),
+		"rm":             DeleteCommandFactory(ui),
+		"rollback":       RollbackCommandFactory(ui),
+		"rotate":         RotateCommandFactory(ui),
+		"template":       TemplateCommandFactory(ui),
+		"tree":           TreeCommandFactory(ui),
+		"shell":          ShellCommandFactory(ui),
+		"undelete":       UndeleteCommandFactory(ui),
 	}
 }
 
@@ -2,10 +2,12 @@ package vc
 
 import (
 	"errors"
+	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/vault/api"
 	"github.com/mitchellh/cli"
 )
 
@@ -14,6 +16,52 @@ type testCommand struct {
 	Args    []string
 	Code    int
 	Live    bool
+	Client  *Client
+}
+
+// setClient overrides the client used by a command
+func (cmd *baseCommand) setClient(c *Client) {
+	cmd.c = c
+}
+
+// testBackend returns an in-memory Vault with a KV version 1 mount at secret/
+// and a KV version 2 mount at kv/
+func testBackend() *MemoryBackend {
+	backend := NewMemoryBackend()
+	backend.Mount("kv", "kv", map[string]string{"version": "2"})
+	return backend
+}
+
+// testMemoryClient returns a Client operating directly on an in-memory Vault
+func testMemoryClient() (*MemoryBackend, *Client) {
+	backend := testBackend()
+	return backend, NewBackendClient(backend)
+}
+
+// testServerClient returns a Client operating on an in-memory Vault over HTTP
+func testServerClient(t *testing.T) (*MemoryBackend, *Client) {
+	t.Helper()
+
+	backend := testBackend()
+	server := httptest.NewServer(backend)
+	t.Cleanup(server.Close)
+
+	config := api.DefaultConfig()
+	config.Address = server.URL
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetToken("test")
+	return backend, client
+}
+
+// testWrite writes a secret or fails the test
+func testWrite(t *testing.T, c *Client, path string, data map[string]interface{}) {
+	t.Helper()
+	if _, err := c.Write(path, data); err != nil {
+		t.Fatal(err)
+	}
 }
 
 func testLiveAvailable() error {
@@ -50,6 +98,19 @@ func testCommandRun(t *testing.T, test testCommand) {
 		t.Fatalf("expected %T to return a Command, got nil", test.Factory)
 	}
 
+	if test.Client != nil {
+		factory := app.Commands["test"]
+		app.Commands["test"] = func() (cli.Command, error) {
+			cmd, err := factory()
+			if err == nil {
+				cmd.(interface {
+					setClient(*Client)
+				}).setClient(test.Client)
+			}
+			return cmd, err
+		}
+	}
+
 	if code, err := app.Run(); err != nil {
 		t.Fatal(err)
 	} else if code != test.Code {
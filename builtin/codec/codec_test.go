@@ -0,0 +1,213 @@
+package codec
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/tehmaze/vc"
+)
+
+// testVaultData is secret data as decoded from Vault, with JSON numbers and a
+// type marker
+func testVaultData(kind string) map[string]interface{} {
+	return map[string]interface{}{
+		vc.CodecTypeKey: kind,
+		"name":          "app",
+		"port":          json.Number("5432"),
+		"ratio":         json.Number("0.5"),
+		"debug":         true,
+		"zip":           "0123",
+		"database": map[string]interface{}{
+			"user":     "admin",
+			"password": "it's a \"secret\" = #1\nline 2",
+			"pool":     json.Number("10"),
+		},
+	}
+}
+
+func testRoundTrip(t *testing.T, kind string) map[string]interface{} {
+	t.Helper()
+
+	c, err := vc.CodecFor(kind)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := c.Marshal("test", testVaultData(kind))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := c.Unmarshal(b)
+	if err != nil {
+		t.Fatalf("%v in:\n%s", err, b)
+	}
+	return data
+}
+
+func TestTOMLCodec(t *testing.T) {
+	want := map[string]interface{}{
+		"name":  "app",
+		"port":  int64(5432),
+		"ratio": 0.5,
+		"debug": true,
+		"zip":   "0123",
+		"database": map[string]interface{}{
+			"user":     "admin",
+			"password": "it's a \"secret\" = #1\nline 2",
+			"pool":     int64(10),
+		},
+	}
+	if got := testRoundTrip(t, "toml"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %#v, got %#v", want, got)
+	}
+}
+
+func TestINICodec(t *testing.T) {
+	want := map[string]interface{}{
+		"name":  "app",
+		"port":  int64(5432),
+		"ratio": 0.5,
+		"debug": true,
+		"zip":   "0123",
+		"database": map[string]interface{}{
+			"user":     "admin",
+			"password": "it's a \"secret\" = #1\nline 2",
+			"pool":     int64(10),
+		},
+	}
+	if got := testRoundTrip(t, "ini"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %#v, got %#v", want, got)
+	}
+
+	c, _ := vc.CodecFor("ini")
+	data, err := c.Unmarshal([]byte("; comment\nkey = value\n\n[section]\nenabled = false\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = map[string]interface{}{
+		"key":     "value",
+		"section": map[string]interface{}{"enabled": false},
+	}
+	if !reflect.DeepEqual(data, want) {
+		t.Fatalf("expected %#v, got %#v", want, data)
+	}
+}
+
+func TestDotenvCodec(t *testing.T) {
+	want := map[string]interface{}{
+		"name":              "app",
+		"port":              "5432",
+		"ratio":             "0.5",
+		"debug":             "true",
+		"zip":               "0123",
+		"database_user":     "admin",
+		"database_password": "it's a \"secret\" = #1\nline 2",
+		"database_pool":     "10",
+	}
+	if got := testRoundTrip(t, "dotenv"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %#v, got %#v", want, got)
+	}
+
+	c, _ := vc.CodecFor("dotenv")
+	data, err := c.Unmarshal([]byte(`# comment
+export A=1
+B = 'single $quoted'
+C=unquoted # comment
+D="multi
+line"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = map[string]interface{}{
+		"A": "1",
+		"B": "single $quoted",
+		"C": "unquoted",
+		"D": "multi\nline",
+	}
+	if !reflect.DeepEqual(data, want) {
+		t.Fatalf("expected %#v, got %#v", want, data)
+	}
+
+	if _, err = c.Unmarshal([]byte("A=\"unterminated\n")); err == nil {
+		t.Fatal("expected error for unterminated quote")
+	}
+}
+
+func TestPropertiesCodec(t *testing.T) {
+	want := map[string]interface{}{
+		"name":              "app",
+		"port":              "5432",
+		"ratio":             "0.5",
+		"debug":             "true",
+		"zip":               "0123",
+		"database.user":     "admin",
+		"database.password": "it's a \"secret\" = #1\nline 2",
+		"database.pool":     "10",
+	}
+	if got := testRoundTrip(t, "properties"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %#v, got %#v", want, got)
+	}
+
+	c, _ := vc.CodecFor("properties")
+	data, err := c.Unmarshal([]byte(`# comment
+! comment
+a = 1
+b:2
+c 3
+long = first \
+       second
+key\ with\ spaces = café 😀
+empty
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = map[string]interface{}{
+		"a":               "1",
+		"b":               "2",
+		"c":               "3",
+		"long":            "first second",
+		"key with spaces": "café \U0001F600",
+		"empty":           "",
+	}
+	if !reflect.DeepEqual(data, want) {
+		t.Fatalf("expected %#v, got %#v", want, data)
+	}
+}
+
+func TestFileCodec(t *testing.T) {
+	var (
+		buf = new(bytes.Buffer)
+		w   = gzip.NewWriter(buf)
+	)
+	w.Write([]byte("hello, world\n"))
+	w.Close()
+
+	c, _ := vc.CodecFor("file")
+	b, err := c.Marshal("test", map[string]interface{}{
+		vc.CodecTypeKey: "file",
+		"contents":      base64.StdEncoding.EncodeToString(buf.Bytes()),
+		"encoding":      "gzip",
+		"size":          json.Number("13"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "hello, world\n" {
+		t.Fatalf("expected decompressed contents, got %q", b)
+	}
+
+	data, err := c.Unmarshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err = c.Marshal("test", data); err != nil {
+		t.Fatal(err)
+	} else if string(b) != "hello, world\n" {
+		t.Fatalf("round trip changed contents to %q", b)
+	}
+}
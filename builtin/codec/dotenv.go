@@ -0,0 +1,118 @@
+package codec
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/tehmaze/vc"
+)
+
+// dotenvCodec reads and writes KEY=value lines as used by .env files. Nested
+// maps are flattened with an underscore, all values are decoded as strings.
+type dotenvCodec struct {
+}
+
+func (c dotenvCodec) Marshal(_ string, data map[string]interface{}) ([]byte, error) {
+	keys, values, err := flatten(data, "_")
+	if err != nil {
+		return nil, fmt.Errorf("vc: dotenv %v", err)
+	}
+
+	buf := new(bytes.Buffer)
+	for _, key := range keys {
+		fmt.Fprintf(buf, "%s=%s\n", key, vc.QuoteDotenv(values[key]))
+	}
+	return buf.Bytes(), nil
+}
+
+func (c dotenvCodec) Unmarshal(p []byte) (map[string]interface{}, error) {
+	var (
+		data    = make(map[string]interface{})
+		scanner = bufio.NewScanner(bytes.NewReader(p))
+		number  int
+	)
+	for scanner.Scan() {
+		number++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		i := strings.IndexByte(line, '=')
+		if i < 1 {
+			return nil, fmt.Errorf("vc: dotenv line %d: expected KEY=value", number)
+		}
+		key, value := strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+
+		switch {
+		case strings.HasPrefix(value, `"`):
+			// Double quoted values may span multiple lines
+			for !dotenvClosed(value) && scanner.Scan() {
+				number++
+				value += "\n" + scanner.Text()
+			}
+			if !dotenvClosed(value) {
+				return nil, fmt.Errorf("vc: dotenv line %d: unterminated quote", number)
+			}
+			value = unquoteDotenv(value[1:strings.LastIndexByte(value, '"')])
+		case strings.HasPrefix(value, "'"):
+			j := strings.IndexByte(value[1:], '\'')
+			if j < 0 {
+				return nil, fmt.Errorf("vc: dotenv line %d: unterminated quote", number)
+			}
+			value = value[1 : j+1]
+		default:
+			if j := strings.Index(value, " #"); j >= 0 {
+				value = strings.TrimSpace(value[:j])
+			}
+		}
+		data[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// dotenvClosed checks if a double quoted value has its closing quote
+func dotenvClosed(value string) bool {
+	for i := 1; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case '"':
+			return true
+		}
+	}
+	return false
+}
+
+// unquoteDotenv resolves the escapes in a double quoted value
+func unquoteDotenv(s string) string {
+	buf := new(bytes.Buffer)
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			buf.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			buf.WriteByte('\n')
+		case 'r':
+			buf.WriteByte('\r')
+		case 't':
+			buf.WriteByte('\t')
+		default:
+			buf.WriteByte(s[i])
+		}
+	}
+	return buf.String()
+}
+
+func init() {
+	vc.RegisterCodec("dotenv", new(dotenvCodec))
+}
@@ -2,14 +2,20 @@ package codec
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"io"
 
 	"github.com/tehmaze/vc"
 )
 
-const fileContentsKey = "contents"
+const (
+	fileContentsKey = "contents"
+	fileSizeKey     = "size"
+	fileSHA256Key   = "sha256"
+)
 
 var (
 	// ErrFileContentsMissing indicates the special contents key is missing in the Vault secret
@@ -19,12 +25,12 @@ var (
 type fileCodec struct{}
 
 func (c fileCodec) Marshal(_ string, data map[string]interface{}) ([]byte, error) {
-	contents, ok := data[fileContentsKey].(string)
-	if !ok {
+	if _, ok := data[fileContentsKey].(string); !ok {
 		return nil, ErrFileContentsMissing
 	}
 
-	return base64.StdEncoding.DecodeString(contents)
+	// Decompresses and verifies the contents
+	return vc.FileContents(data)
 }
 
 func (c fileCodec) Unmarshal(p []byte) (map[string]interface{}, error) {
@@ -40,9 +46,12 @@ func (c fileCodec) Unmarshal(p []byte) (map[string]interface{}, error) {
 		breaker.Close()
 	}
 
+	sum := sha256.Sum256(p)
 	return map[string]interface{}{
 		vc.CodecTypeKey: "file",
 		fileContentsKey: out.String(),
+		fileSizeKey:     len(p),
+		fileSHA256Key:   hex.EncodeToString(sum[:]),
 	}, nil
 }
 
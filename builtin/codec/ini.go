@@ -0,0 +1,42 @@
+package codec
+
+import (
+	"github.com/tehmaze/vc"
+	ini "gopkg.in/ini.v1"
+)
+
+// iniCodec maps the keys of the default section to top level keys, and other
+// sections to nested maps. Sections nested deeper are joined with a dot.
+// Values that are canonical booleans or numbers are decoded as such.
+type iniCodec struct {
+}
+
+func (c iniCodec) Marshal(_ string, data map[string]interface{}) ([]byte, error) {
+	return vc.FormatINI(data)
+}
+
+func (c iniCodec) Unmarshal(p []byte) (map[string]interface{}, error) {
+	file, err := ini.LoadSources(ini.LoadOptions{
+		AllowPythonMultilineValues: false,
+	}, p)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make(map[string]interface{})
+	for _, section := range file.Sections() {
+		values := data
+		if name := section.Name(); name != ini.DefaultSection {
+			values = make(map[string]interface{})
+			data[name] = values
+		}
+		for _, key := range section.Keys() {
+			values[key.Name()] = inferValue(key.Value())
+		}
+	}
+	return data, nil
+}
+
+func init() {
+	vc.RegisterCodec("ini", new(iniCodec))
+}
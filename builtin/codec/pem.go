@@ -0,0 +1,269 @@
+package codec
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tehmaze/vc"
+)
+
+// Keys of pem secrets
+const (
+	pemCertificateKey = "certificate"
+	pemChainKey       = "chain"
+	pemCAKey          = "ca"
+	pemPrivateKeyKey  = "private_key"
+)
+
+// pemKeys in the order they are marshaled
+var pemKeys = []string{pemCertificateKey, pemChainKey, pemCAKey, pemPrivateKeyKey}
+
+var (
+	// ErrPEMMissing indicates there is no PEM material
+	ErrPEMMissing = errors.New("vc: no PEM certificates or keys found")
+
+	// ErrPEMKeyMismatch indicates the private key does not belong to the certificate
+	ErrPEMKeyMismatch = errors.New("vc: private key does not match certificate")
+)
+
+// pemCodec stores a certificate, its chain, the CA certificates and the
+// private key as separate PEM encoded keys
+type pemCodec struct{}
+
+func (c pemCodec) Marshal(_ string, data map[string]interface{}) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	for _, key := range pemKeys {
+		if s, ok := data[key].(string); ok && s != "" {
+			buf.WriteString(strings.TrimSpace(s))
+			buf.WriteByte('\n')
+		}
+	}
+	if buf.Len() == 0 {
+		return nil, ErrPEMMissing
+	}
+	return buf.Bytes(), nil
+}
+
+func (c pemCodec) Unmarshal(p []byte) (map[string]interface{}, error) {
+	var (
+		certs []*x509.Certificate
+		key   *pem.Block
+	)
+	for {
+		var block *pem.Block
+		if block, p = pem.Decode(p); block == nil {
+			break
+		}
+		switch {
+		case block.Type == "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			certs = append(certs, cert)
+		case strings.HasSuffix(block.Type, "PRIVATE KEY"):
+			if key != nil {
+				return nil, errors.New("vc: multiple private keys found")
+			}
+			if x509.IsEncryptedPEMBlock(block) || block.Type == "ENCRYPTED PRIVATE KEY" {
+				return nil, errors.New("vc: encrypted private keys are not supported")
+			}
+			key = block
+		}
+	}
+	if len(certs) == 0 && key == nil {
+		return nil, ErrPEMMissing
+	}
+
+	// Find the leaf certificate, if we have a key it must match
+	leaf := -1
+	if key != nil {
+		private, err := parsePrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		for i, cert := range certs {
+			if publicKeyEqual(private.Public(), cert.PublicKey) {
+				leaf = i
+				break
+			}
+		}
+		if leaf == -1 && len(certs) > 0 {
+			return nil, ErrPEMKeyMismatch
+		}
+	} else {
+		for i, cert := range certs {
+			if !cert.IsCA {
+				leaf = i
+				break
+			}
+		}
+		if leaf == -1 && len(certs) > 0 {
+			leaf = 0
+		}
+	}
+
+	var chain, ca bytes.Buffer
+	data := map[string]interface{}{
+		vc.CodecTypeKey: "pem",
+	}
+	for i, cert := range certs {
+		block := &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}
+		switch {
+		case i == leaf:
+			data[pemCertificateKey] = string(pem.EncodeToMemory(block))
+		case cert.IsCA && bytes.Equal(cert.RawSubject, cert.RawIssuer):
+			pem.Encode(&ca, block)
+		default:
+			pem.Encode(&chain, block)
+		}
+	}
+	if chain.Len() > 0 {
+		data[pemChainKey] = chain.String()
+	}
+	if ca.Len() > 0 {
+		data[pemCAKey] = ca.String()
+	}
+	if key != nil {
+		data[pemPrivateKeyKey] = string(pem.EncodeToMemory(key))
+	}
+	return data, nil
+}
+
+// Summary describes the certificates and private key
+func (c pemCodec) Summary(path string, data map[string]interface{}) ([]byte, error) {
+	var (
+		buf = new(bytes.Buffer)
+		now = time.Now()
+	)
+	for _, key := range []string{pemCertificateKey, pemChainKey, pemCAKey} {
+		s, _ := data[key].(string)
+		certs, err := vc.ParseCertificates([]byte(s))
+		if err != nil {
+			return nil, fmt.Errorf("vc: %s: %s: %v", path, key, err)
+		}
+		for _, cert := range certs {
+			fmt.Fprintf(buf, "%s:\n", key)
+			fmt.Fprintf(buf, "  subject:    %s\n", cert.Subject)
+			fmt.Fprintf(buf, "  issuer:     %s\n", cert.Issuer)
+			if names := certificateNames(cert); len(names) > 0 {
+				fmt.Fprintf(buf, "  names:      %s\n", strings.Join(names, ", "))
+			}
+			fmt.Fprintf(buf, "  serial:     %s\n", cert.SerialNumber)
+			fmt.Fprintf(buf, "  not before: %s\n", cert.NotBefore.UTC().Format(time.RFC3339))
+			fmt.Fprintf(buf, "  not after:  %s (%s)\n", cert.NotAfter.UTC().Format(time.RFC3339), expiry(cert.NotAfter, now))
+		}
+	}
+
+	if s, ok := data[pemPrivateKeyKey].(string); ok && s != "" {
+		block, _ := pem.Decode([]byte(s))
+		if block == nil {
+			return nil, fmt.Errorf("vc: %s: invalid private key", path)
+		}
+		private, err := parsePrivateKey(block)
+		if err != nil {
+			return nil, fmt.Errorf("vc: %s: %v", path, err)
+		}
+		fmt.Fprintf(buf, "%s:\n  type:       %s\n", pemPrivateKeyKey, describeKey(private))
+		if s, ok := data[pemCertificateKey].(string); ok {
+			if certs, _ := vc.ParseCertificates([]byte(s)); len(certs) > 0 {
+				fmt.Fprintf(buf, "  matches:    %t\n", publicKeyEqual(private.Public(), certs[0].PublicKey))
+			}
+		}
+	}
+
+	if buf.Len() == 0 {
+		return nil, ErrPEMMissing
+	}
+	return buf.Bytes(), nil
+}
+
+// privateKey is implemented by all private keys in crypto
+type privateKey interface {
+	Public() crypto.PublicKey
+}
+
+// parsePrivateKey parses PKCS#1, PKCS#8 and EC private keys
+func parsePrivateKey(block *pem.Block) (privateKey, error) {
+	var (
+		key interface{}
+		err error
+	)
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+	private, ok := key.(privateKey)
+	if !ok {
+		return nil, fmt.Errorf("vc: unsupported private key %T", key)
+	}
+	return private, nil
+}
+
+// publicKeyEqual compares public keys
+func publicKeyEqual(a, b crypto.PublicKey) bool {
+	if k, ok := a.(interface {
+		Equal(crypto.PublicKey) bool
+	}); ok {
+		return k.Equal(b)
+	}
+	return false
+}
+
+func describeKey(key privateKey) string {
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		return fmt.Sprintf("RSA %d bits", key.N.BitLen())
+	case *ecdsa.PrivateKey:
+		return fmt.Sprintf("ECDSA %s", key.Curve.Params().Name)
+	case ed25519.PrivateKey:
+		return "Ed25519"
+	default:
+		return fmt.Sprintf("%T", key)
+	}
+}
+
+// certificateNames returns the DNS names, IP addresses and e-mail addresses
+func certificateNames(cert *x509.Certificate) []string {
+	names := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	names = append(names, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		names = append(names, uri.String())
+	}
+	return names
+}
+
+// expiry describes the time until expiry in days
+func expiry(notAfter, now time.Time) string {
+	switch days := vc.ExpiryDays(notAfter, now); {
+	case days < 0:
+		return fmt.Sprintf("expired %d days ago", -days)
+	case days == 1:
+		return "expires in 1 day"
+	default:
+		return fmt.Sprintf("expires in %d days", days)
+	}
+}
+
+func init() {
+	vc.RegisterCodec("pem", new(pemCodec))
+}
@@ -0,0 +1,143 @@
+package codec
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tehmaze/vc"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func (c testCert) keyPEM(t *testing.T) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(c.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+// testIssue issues a certificate, self-signed if parent is nil
+func testIssue(t *testing.T, name string, ca bool, parent *testCert) testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(90 * 24 * time.Hour),
+		IsCA:                  ca,
+		BasicConstraintsValid: true,
+	}
+	if !ca {
+		template.DNSNames = []string{name}
+	}
+
+	issuer, signer := template, key
+	if parent != nil {
+		issuer, signer = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testCert{
+		cert: cert,
+		key:  key,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+func TestPEMCodec(t *testing.T) {
+	var (
+		root         = testIssue(t, "Root CA", true, nil)
+		intermediate = testIssue(t, "Intermediate CA", true, &root)
+		leaf         = testIssue(t, "example.org", false, &intermediate)
+		other        = testIssue(t, "example.com", false, &intermediate)
+	)
+
+	c, err := vc.CodecFor("pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Key first, CA and leaf in odd order
+	bundle := bytes.Join([][]byte{leaf.keyPEM(t), root.pem, leaf.pem, intermediate.pem}, nil)
+	data, err := c.Unmarshal(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string][]byte{
+		vc.CodecTypeKey:   []byte("pem"),
+		pemCertificateKey: leaf.pem,
+		pemChainKey:       intermediate.pem,
+		pemCAKey:          root.pem,
+		pemPrivateKeyKey:  leaf.keyPEM(t),
+	} {
+		if got, _ := data[key].(string); got != string(want) {
+			t.Fatalf("expected %s:\n%s\ngot:\n%s", key, want, got)
+		}
+	}
+
+	// Round trip
+	delete(data, vc.CodecTypeKey)
+	b, err := c.Marshal("test", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, err := c.Unmarshal(b); err != nil {
+		t.Fatal(err)
+	} else if again[pemCertificateKey] != data[pemCertificateKey] || again[pemPrivateKeyKey] != data[pemPrivateKeyKey] {
+		t.Fatalf("round trip changed data:\n%s", b)
+	}
+
+	// Summary
+	s, err := c.(vc.Summarizer).Summary("test", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"subject:    CN=example.org",
+		"issuer:     CN=Intermediate CA",
+		"names:      example.org",
+		"(expires in 89 days)",
+		"type:       ECDSA P-256",
+		"matches:    true",
+	} {
+		if !strings.Contains(string(s), want) {
+			t.Fatalf("expected summary to contain %q, got:\n%s", want, s)
+		}
+	}
+
+	// Mismatched key
+	if _, err = c.Unmarshal(append(other.keyPEM(t), leaf.pem...)); err != ErrPEMKeyMismatch {
+		t.Fatalf("expected %v, got %v", ErrPEMKeyMismatch, err)
+	}
+
+	// No PEM
+	if _, err = c.Unmarshal([]byte("test")); err != ErrPEMMissing {
+		t.Fatalf("expected %v, got %v", ErrPEMMissing, err)
+	}
+}
@@ -0,0 +1,149 @@
+package codec
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/tehmaze/vc"
+)
+
+// propertiesCodec reads and writes Java properties files. Nested maps are
+// flattened with a dot, all values are decoded as strings.
+type propertiesCodec struct {
+}
+
+func (c propertiesCodec) Marshal(_ string, data map[string]interface{}) ([]byte, error) {
+	keys, values, err := flatten(data, ".")
+	if err != nil {
+		return nil, fmt.Errorf("vc: properties %v", err)
+	}
+
+	buf := new(bytes.Buffer)
+	for _, key := range keys {
+		fmt.Fprintf(buf, "%s=%s\n", vc.EscapeProperties(key, true), vc.EscapeProperties(values[key], false))
+	}
+	return buf.Bytes(), nil
+}
+
+func (c propertiesCodec) Unmarshal(p []byte) (map[string]interface{}, error) {
+	var (
+		data    = make(map[string]interface{})
+		scanner = bufio.NewScanner(bytes.NewReader(p))
+		logical string
+	)
+	for scanner.Scan() {
+		line := strings.TrimLeft(scanner.Text(), " \t\f")
+		if logical == "" && (line == "" || line[0] == '#' || line[0] == '!') {
+			continue
+		}
+
+		// A line ending in an odd number of backslashes continues
+		if trailing := len(line) - len(strings.TrimRight(line, `\`)); trailing%2 == 1 {
+			logical += line[:len(line)-1]
+			continue
+		}
+		logical += line
+
+		key, value, err := splitProperty(logical)
+		if err != nil {
+			return nil, err
+		}
+		data[key] = value
+		logical = ""
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if logical != "" {
+		key, value, err := splitProperty(logical)
+		if err != nil {
+			return nil, err
+		}
+		data[key] = value
+	}
+	return data, nil
+}
+
+// splitProperty splits a logical line in key and value; the key ends at the
+// first unescaped separator (=, : or whitespace)
+func splitProperty(line string) (key, value string, err error) {
+	end := len(line)
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.IndexByte("=: \t\f", line[i]) >= 0 {
+			end = i
+			break
+		}
+	}
+
+	rest := strings.TrimLeft(line[end:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+
+	if key, err = unescapeProperties(line[:end]); err != nil {
+		return
+	}
+	value, err = unescapeProperties(rest)
+	return
+}
+
+// unescapeProperties resolves escapes, including UTF-16 \uXXXX sequences
+func unescapeProperties(s string) (string, error) {
+	var (
+		buf   = new(bytes.Buffer)
+		units []uint16
+	)
+	flush := func() {
+		if len(units) > 0 {
+			buf.WriteString(string(utf16.Decode(units)))
+			units = units[:0]
+		}
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			flush()
+			buf.WriteByte(s[i])
+			continue
+		}
+		i++
+		if s[i] == 'u' {
+			if i+5 > len(s) {
+				return "", fmt.Errorf("vc: properties: invalid escape %q", s[i-1:])
+			}
+			u, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("vc: properties: invalid escape %q", s[i-1:i+5])
+			}
+			units = append(units, uint16(u))
+			i += 4
+			continue
+		}
+		flush()
+		switch s[i] {
+		case 'n':
+			buf.WriteByte('\n')
+		case 'r':
+			buf.WriteByte('\r')
+		case 't':
+			buf.WriteByte('\t')
+		case 'f':
+			buf.WriteByte('\f')
+		default:
+			buf.WriteByte(s[i])
+		}
+	}
+	flush()
+	return buf.String(), nil
+}
+
+func init() {
+	vc.RegisterCodec("properties", new(propertiesCodec))
+}
@@ -0,0 +1,31 @@
+package codec
+
+import (
+	"bytes"
+
+	"github.com/BurntSushi/toml"
+	"github.com/tehmaze/vc"
+)
+
+type tomlCodec struct {
+}
+
+func (c tomlCodec) Marshal(_ string, data map[string]interface{}) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := toml.NewEncoder(buf).Encode(vc.TypedData(data)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c tomlCodec) Unmarshal(p []byte) (map[string]interface{}, error) {
+	var data map[string]interface{}
+	if _, err := toml.Decode(string(p), &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func init() {
+	vc.RegisterCodec("toml", new(tomlCodec))
+}
@@ -0,0 +1,38 @@
+package codec
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/tehmaze/vc"
+)
+
+// inferValue converts a string to a bool, int64 or float64 if the string is
+// the canonical representation of that value
+func inferValue(s string) interface{} {
+	if b, err := strconv.ParseBool(s); err == nil && strconv.FormatBool(b) == s {
+		return b
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil && strconv.FormatInt(i, 10) == s {
+		return i
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil && strconv.FormatFloat(f, 'g', -1, 64) == s {
+		return f
+	}
+	return s
+}
+
+// flatten returns the sorted keys and the string values of data, with the
+// keys of nested maps joined with sep
+func flatten(data map[string]interface{}, sep string) ([]string, map[string]string, error) {
+	values := make(map[string]string)
+	if err := vc.Flatten(values, "", sep, data); err != nil {
+		return nil, nil, err
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, values, nil
+}
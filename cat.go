@@ -20,7 +20,10 @@ type CatCommand struct {
 	fs            *flag.FlagSet
 	key           string
 	mod           string
+	format        string
+	version       int
 	ignoreMissing bool
+	summary       bool
 }
 
 func (cmd *CatCommand) Help() string {
@@ -42,6 +45,11 @@ func (cmd *CatCommand) Run(args []string) int {
 		cmd.mode = os.FileMode(mode)
 	}
 
+	if _, ok := formatters[cmd.format]; cmd.format != "" && !ok {
+		cmd.ui.Error(fmt.Sprintf("error: unsupported format %q, supported are %s", cmd.format, strings.Join(formatNames(), ", ")))
+		return SyntaxError
+	}
+
 	c, err := cmd.Client()
 	if err != nil {
 		cmd.ui.Error(err.Error())
@@ -57,7 +65,12 @@ func (cmd *CatCommand) Run(args []string) int {
 	buf := new(bytes.Buffer)
 	for _, path := range args {
 		Debugf("cat: read %q", strings.TrimLeft(path, "/"))
-		s, err := c.Logical().Read(strings.TrimLeft(path, "/"))
+		var s *api.Secret
+		if cmd.version > 0 {
+			s, err = c.ReadVersion(path, cmd.version)
+		} else {
+			s, err = c.Read(path)
+		}
 		if err != nil {
 			cmd.ui.Error(err.Error())
 			return ServerError
@@ -67,7 +80,10 @@ func (cmd *CatCommand) Run(args []string) int {
 			return SyntaxError
 		}
 		var ret int
-		if cmd.key == "" {
+		if cmd.key == "" && cmd.format != "" {
+			// Explicit output format
+			ret = cmd.runFormat(path, s, buf)
+		} else if cmd.key == "" {
 			// No explicit key given
 			if _, ok := s.Data[CodecTypeKey]; ok {
 				// But the __TYPE__ key is available
@@ -102,26 +118,6 @@ func (cmd *CatCommand) Run(args []string) int {
 	return Success
 }
 
-// globs expands one or more paths containing glob(s)
-func (cmd *CatCommand) globs(c *Client, patterns []string) (expanded []string, err error) {
-	for _, pattern := range patterns {
-		var infos []os.FileInfo
-		if c.isGlob(pattern) {
-			if infos, err = c.Glob(pattern); err != nil {
-				return
-			}
-		} else {
-			expanded = append(expanded, pattern)
-		}
-		if len(infos) > 0 {
-			for _, info := range infos {
-				expanded = append(expanded, info.Name())
-			}
-		}
-	}
-	return
-}
-
 func (cmd *CatCommand) run(path string, s *api.Secret, buf io.Writer) int {
 	enc := json.NewEncoder(buf)
 	enc.SetIndent("", "  ")
@@ -133,6 +129,21 @@ func (cmd *CatCommand) run(path string, s *api.Secret, buf io.Writer) int {
 	return Success
 }
 
+func (cmd *CatCommand) runFormat(path string, s *api.Secret, buf io.Writer) int {
+	b, err := formatData(cmd.format, s.Data)
+	if err != nil {
+		cmd.ui.Error(fmt.Sprintf("error: %s: %v", path, err))
+		return CodecError
+	}
+
+	if _, err = buf.Write(b); err != nil {
+		cmd.ui.Error(err.Error())
+		return SystemError
+	}
+
+	return Success
+}
+
 func (cmd *CatCommand) runKeyed(path string, s *api.Secret, buf io.Writer) int {
 	val, ok := s.Data[cmd.key]
 	if !ok {
@@ -143,14 +154,10 @@ func (cmd *CatCommand) runKeyed(path string, s *api.Secret, buf io.Writer) int {
 		return SyntaxError
 	}
 
-	var err error
-	switch val := val.(type) {
-	case []byte:
-		_, err = buf.Write(val)
-	case string:
-		_, err = buf.Write([]byte(val))
-	default:
-		err = fmt.Errorf("vc: can't cat type %T", val)
+	// Non-string values are encoded as JSON
+	str, err := ValueString(val)
+	if err == nil {
+		_, err = io.WriteString(buf, str)
 	}
 	if err != nil {
 		cmd.ui.Error(fmt.Sprintf("error: %s: key %q: %v", path, cmd.key, err))
@@ -170,7 +177,13 @@ func (cmd *CatCommand) runTyped(path string, s *api.Secret, buf io.Writer) int {
 		return SyntaxError
 	}
 
-	delete(s.Data, CodecTypeKey)
+	// Codecs are passed the data without type marker
+	data := make(map[string]interface{}, len(s.Data))
+	for key, value := range s.Data {
+		if key != CodecTypeKey {
+			data[key] = value
+		}
+	}
 
 	c, err := CodecFor(encoderType)
 	if err != nil {
@@ -179,7 +192,40 @@ func (cmd *CatCommand) runTyped(path string, s *api.Secret, buf io.Writer) int {
 	}
 
 	var b []byte
-	if b, err = c.Marshal(path, s.Data); err != nil {
+	if isChunked(s.Data) {
+		// Chunked files are reassembled from their chunk secrets
+		client, err := cmd.Client()
+		if err != nil {
+			cmd.ui.Error(err.Error())
+			return ClientError
+		}
+		// Only the chunks of the current version are kept
+		if cmd.version > 0 {
+			meta, err := client.Metadata(path)
+			if err != nil {
+				cmd.ui.Error(err.Error())
+				return ServerError
+			}
+			if meta == nil || meta.CurrentVersion != cmd.version {
+				cmd.ui.Error(fmt.Sprintf("error: %s: only the current version of a chunked file can be read", path))
+				return SyntaxError
+			}
+		}
+		if b, err = client.readFile(path, s.Data); err != nil {
+			cmd.ui.Error(err.Error())
+			return ServerError
+		}
+	} else if cmd.summary {
+		summarizer, ok := c.(Summarizer)
+		if !ok {
+			cmd.ui.Error(fmt.Sprintf("error: %s: type %q has no summary", path, encoderType))
+			return CodecError
+		}
+		b, err = summarizer.Summary(path, data)
+	} else {
+		b, err = c.Marshal(path, data)
+	}
+	if err != nil {
 		cmd.ui.Error(err.Error())
 		return SystemError
 	}
@@ -207,10 +253,14 @@ func CatCommandFactory(ui cli.Ui) cli.CommandFactory {
 		cmd.fs = flag.NewFlagSet("cat", flag.ContinueOnError)
 		cmd.fs.BoolVar(&cmd.ignoreMissing, "i", false, "ingore missing key")
 		cmd.fs.StringVar(&cmd.key, "k", "", "key")
+		cmd.fs.StringVar(&cmd.format, "format", "", "output format: "+strings.Join(formatNames(), ", ")+" (default: json, or by type marker)")
 		cmd.fs.StringVar(&cmd.mod, "m", "0600", "output mode")
+		cmd.fs.BoolVar(&cmd.summary, "summary", false, "show a human readable summary of typed secrets (if supported by the type)")
 		cmd.fs.StringVar(&cmd.out, "o", "", "output (default stdout)")
+		cmd.encryptFlags(cmd.fs)
 		cmd.fs.StringVar(&cmd.user, "u", "", "output file user name or numeric user id (default: current user)")
 		cmd.fs.StringVar(&cmd.group, "g", "", "output file group name or numeric group id (default: current group)")
+		cmd.fs.IntVar(&cmd.version, "V", 0, "read version (KV version 2, default: current version)")
 		cmd.fs.Usage = func() {
 			fmt.Print(cmd.Help())
 		}
@@ -0,0 +1,301 @@
+package vc
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mitchellh/cli"
+)
+
+// Expiring is the return code of certs if any certificate is within the
+// window; errors return a code above 1
+const Expiring = 1
+
+// CertsCommand scans secrets for (expiring) certificates
+type CertsCommand struct {
+	baseCommand
+	fs       *flag.FlagSet
+	recurse  bool
+	all      bool
+	json     bool
+	expiring string
+}
+
+// certInfo describes a certificate found in a secret
+type certInfo struct {
+	Path     string    `json:"path"`
+	Key      string    `json:"key"`
+	Subject  string    `json:"subject"`
+	Issuer   string    `json:"issuer"`
+	Names    []string  `json:"names,omitempty"`
+	NotAfter time.Time `json:"not_after"`
+	Days     int       `json:"days"`
+	Expiring bool      `json:"expiring"`
+}
+
+func (cmd *CertsCommand) Help() string {
+	return "Usage: vc certs [<options>] <secret path> [... <secret path>]\n\n" +
+		"The exit status is 0 if no certificate expires within the window, 1 if any\n" +
+		"does and above 1 on errors.\n\nOptions:\n" + defaults(cmd.fs)
+}
+
+func (cmd *CertsCommand) Run(args []string) int {
+	// Help and syntax errors would return 1, which means a certificate expires
+	args, err := parseInterspersed(cmd.fs, args)
+	if err != nil {
+		return UsageError
+	}
+	if len(args) < 1 {
+		cmd.ui.Error(cmd.Help())
+		return UsageError
+	}
+
+	window, err := parseWindow(cmd.expiring)
+	if err != nil {
+		cmd.ui.Error(fmt.Sprintf("error: invalid window %q: %v", cmd.expiring, err))
+		return UsageError
+	}
+
+	client, err := cmd.Client()
+	if err != nil {
+		cmd.ui.Error(err.Error())
+		return ClientError
+	}
+
+	if args, err = cmd.globs(client, args); err != nil {
+		cmd.ui.Error(fmt.Sprintf("error: %v", err))
+		return UsageError
+	}
+
+	var (
+		ret   int
+		certs []certInfo
+		now   = time.Now()
+	)
+	for _, root := range args {
+		var paths []string
+		if cmd.recurse {
+			err = client.Walk(root, func(name string, err error) error {
+				if err != nil {
+					cmd.ui.Error(fmt.Sprintf("%s: %v", name, err))
+					ret = ServerError
+					return nil
+				}
+				paths = append(paths, name)
+				return nil
+			})
+			if err != nil {
+				cmd.ui.Error(err.Error())
+				return ServerError
+			}
+		} else {
+			paths = []string{client.abspath(root)}
+		}
+
+		for _, name := range paths {
+			secret, err := client.Read(name)
+			if err != nil {
+				cmd.ui.Error(fmt.Sprintf("%s: %v", name, err))
+				ret = ServerError
+				continue
+			} else if secret == nil {
+				if !cmd.recurse {
+					cmd.ui.Error(fmt.Sprintf("error: %s: secret not found", name))
+					ret = UsageError
+				}
+				continue
+			}
+
+			found, err := secretCertificates(client, name, secret.Data)
+			if err != nil {
+				cmd.ui.Error(fmt.Sprintf("%s: %v", name, err))
+				ret = CodecError
+			}
+			for _, cert := range found {
+				cert.Days = ExpiryDays(cert.NotAfter, now)
+				cert.Expiring = cert.NotAfter.Before(now.Add(window))
+				if cert.Expiring || cmd.all {
+					certs = append(certs, cert)
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(certs, func(i, j int) bool {
+		return certs[i].NotAfter.Before(certs[j].NotAfter)
+	})
+	if err = cmd.print(certs); err != nil {
+		cmd.ui.Error(fmt.Sprintf("error: %v", err))
+		return SystemError
+	}
+
+	if ret == Success {
+		for _, cert := range certs {
+			if cert.Expiring {
+				return Expiring
+			}
+		}
+	}
+	return ret
+}
+
+func (cmd *CertsCommand) print(certs []certInfo) error {
+	if cmd.json {
+		if certs == nil {
+			certs = []certInfo{}
+		}
+		b, err := json.MarshalIndent(certs, "", "  ")
+		if err != nil {
+			return err
+		}
+		cmd.ui.Output(string(b))
+		return nil
+	}
+	if len(certs) == 0 {
+		return nil
+	}
+
+	buf := new(bytes.Buffer)
+	w := tabwriter.NewWriter(buf, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "DAYS\tEXPIRES\tPATH\tKEY\tSUBJECT")
+	for _, cert := range certs {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", cert.Days,
+			cert.NotAfter.UTC().Format("2006-01-02"),
+			cert.Path, cert.Key, cert.Subject)
+	}
+	w.Flush()
+
+	cmd.ui.Output(strings.TrimRight(buf.String(), "\n"))
+	return nil
+}
+
+// secretCertificates finds the PEM encoded certificates in the contents of
+// file secrets and in string values
+func secretCertificates(client *Client, path string, data map[string]interface{}) ([]certInfo, error) {
+	values := make(map[string]string)
+	if kind, _ := data[CodecTypeKey].(string); kind == "file" {
+		b, err := client.readFile(path, data)
+		if err != nil {
+			return nil, err
+		}
+		values["contents"] = string(b)
+	} else if err := Flatten(values, "", ".", data); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var certs []certInfo
+	for _, key := range keys {
+		found, err := ParseCertificates([]byte(values[key]))
+		if err != nil {
+			return certs, fmt.Errorf("key %q: %v", key, err)
+		}
+		for _, cert := range found {
+			certs = append(certs, certInfo{
+				Path:     path,
+				Key:      key,
+				Subject:  cert.Subject.String(),
+				Issuer:   cert.Issuer.String(),
+				Names:    cert.DNSNames,
+				NotAfter: cert.NotAfter,
+			})
+		}
+	}
+	return certs, nil
+}
+
+// ParseCertificates parses all PEM encoded certificates in p, other PEM blocks
+// are skipped
+func ParseCertificates(p []byte) (certs []*x509.Certificate, err error) {
+	for {
+		var block *pem.Block
+		if block, p = pem.Decode(p); block == nil {
+			return
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		var cert *x509.Certificate
+		if cert, err = x509.ParseCertificate(block.Bytes); err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+}
+
+// ExpiryDays returns the number of whole days until notAfter, negative if the
+// certificate has expired
+func ExpiryDays(notAfter, now time.Time) int {
+	return int(math.Floor(notAfter.Sub(now).Hours() / 24))
+}
+
+// parseWindow parses a duration, with support for days (d) and weeks (w)
+func parseWindow(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+	} {
+		if strings.HasSuffix(s, suffix) {
+			n, err := strconv.ParseFloat(strings.TrimSuffix(s, suffix), 64)
+			if err != nil {
+				return 0, err
+			}
+			return time.Duration(n * float64(unit)), nil
+		}
+	}
+	return time.ParseDuration(s)
+}
+
+// parseInterspersed parses flags that may follow the arguments
+func parseInterspersed(fs *flag.FlagSet, args []string) (rest []string, err error) {
+	for {
+		if err = fs.Parse(args); err != nil {
+			return
+		}
+		if args = fs.Args(); len(args) == 0 {
+			return
+		}
+		rest = append(rest, args[0])
+		args = args[1:]
+	}
+}
+
+func (cmd *CertsCommand) Synopsis() string {
+	return "report (expiring) certificates in secrets"
+}
+
+func CertsCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		cmd := &CertsCommand{
+			baseCommand: baseCommand{
+				ui: ui,
+			},
+		}
+
+		cmd.fs = flag.NewFlagSet("certs", flag.ContinueOnError)
+		cmd.fs.BoolVar(&cmd.recurse, "R", false, "recursively scan secrets below the path")
+		cmd.fs.BoolVar(&cmd.all, "a", false, "report all certificates, not only those within the window")
+		cmd.fs.BoolVar(&cmd.json, "json", false, "output as JSON")
+		cmd.fs.StringVar(&cmd.expiring, "expiring", "30d", "window for expiring certificates, in days (d), weeks (w) or a duration")
+		cmd.fs.Usage = func() {
+			fmt.Print(cmd.Help())
+		}
+
+		return cmd, nil
+	}
+}
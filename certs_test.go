@@ -0,0 +1,151 @@
+package vc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// testCertificate returns a PEM encoded self-signed certificate
+func testCertificate(t *testing.T, name string, notAfter time.Time) string {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func TestParseWindow(t *testing.T) {
+	for s, want := range map[string]time.Duration{
+		"30d":  30 * 24 * time.Hour,
+		"2w":   14 * 24 * time.Hour,
+		"0.5d": 12 * time.Hour,
+		"36h":  36 * time.Hour,
+	} {
+		if got, err := parseWindow(s); err != nil {
+			t.Fatal(err)
+		} else if got != want {
+			t.Fatalf("%q: expected %s, got %s", s, want, got)
+		}
+	}
+	if _, err := parseWindow("soon"); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestSecretCertificates(t *testing.T) {
+	notAfter := time.Now().Add(10 * 24 * time.Hour).Truncate(time.Second)
+	cert := testCertificate(t, "example.org", notAfter)
+
+	_, client := testMemoryClient()
+	certs, err := secretCertificates(client, "/secret/file", map[string]interface{}{
+		CodecTypeKey: "file",
+		"contents":   base64.StdEncoding.EncodeToString([]byte(cert)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(certs) != 1 || certs[0].Key != "contents" || certs[0].Subject != "CN=example.org" || !certs[0].NotAfter.Equal(notAfter) {
+		t.Fatalf("unexpected certificates %+v", certs)
+	}
+	if days := ExpiryDays(certs[0].NotAfter, time.Now()); days != 9 {
+		t.Fatalf("expected 9 days, got %d", days)
+	}
+
+	certs, err = secretCertificates(client, "/secret/values", map[string]interface{}{
+		"password": "-----BEGIN nothing",
+		"tls": map[string]interface{}{
+			"cert": cert + cert,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(certs) != 2 || certs[0].Key != "tls.cert" {
+		t.Fatalf("unexpected certificates %+v", certs)
+	}
+}
+
+func TestCertsCommand(t *testing.T) {
+	now := time.Now()
+
+	_, client := testMemoryClient()
+	testWrite(t, client, "secret/tls/valid", map[string]interface{}{
+		"cert": testCertificate(t, "valid.example.org", now.Add(90*24*time.Hour)),
+	})
+	testWrite(t, client, "kv/tls/expiring", map[string]interface{}{
+		CodecTypeKey:  "pem",
+		"certificate": testCertificate(t, "expiring.example.org", now.Add(7*24*time.Hour)),
+	})
+
+	for _, test := range []testCommand{
+		testCommand{
+			Factory: CertsCommandFactory,
+			Args:    []string{"--help"},
+			Code:    Success,
+		},
+		testCommand{
+			Factory: CertsCommandFactory,
+			Args:    []string{"-expiring"},
+			Code:    UsageError,
+		},
+		testCommand{
+			Factory: CertsCommandFactory,
+			Args:    []string{"-expiring", "later", "secret/tls/valid"},
+			Code:    UsageError,
+			Client:  client,
+		},
+		testCommand{
+			Factory: CertsCommandFactory,
+			Args:    []string{"secret/tls/valid"},
+			Code:    Success,
+			Client:  client,
+		},
+		testCommand{
+			Factory: CertsCommandFactory,
+			Args:    []string{"secret/tls/valid", "-expiring", "100d"},
+			Code:    Expiring,
+			Client:  client,
+		},
+		testCommand{
+			Factory: CertsCommandFactory,
+			Args:    []string{"-R", "-json", "kv/"},
+			Code:    Expiring,
+			Client:  client,
+		},
+		testCommand{
+			Factory: CertsCommandFactory,
+			Args:    []string{"-R", "kv/", "-expiring", "1d"},
+			Code:    Success,
+			Client:  client,
+		},
+		testCommand{
+			Factory: CertsCommandFactory,
+			Args:    []string{"secret/tls/missing"},
+			Code:    UsageError,
+			Client:  client,
+		},
+	} {
+		testCommandRun(t, test)
+	}
+}
@@ -0,0 +1,388 @@
+package vc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// fileChunkSize is the default maximum size of a file stored in a single
+// secret, larger files are split in chunks of this size. Base64 encoded, a
+// chunk stays well below the request size limits of Vault and its storage.
+const fileChunkSize = 256 << 10
+
+// Keys of file secrets
+const (
+	fileContentsKey = "contents"
+	fileSizeKey     = "size"
+	fileSHA256Key   = "sha256"
+	fileChunksKey   = "chunks"
+)
+
+var (
+	// ErrFileContentsMissing indicates the secret is not a file
+	ErrFileContentsMissing = errors.New("vc: file has no contents or chunks")
+
+	// ErrFileChunked indicates the file is stored in chunks
+	ErrFileChunked = errors.New("vc: file is stored in chunks")
+)
+
+// isChunked checks if the secret data is a chunked file manifest; secrets of
+// other types may use the chunks key for their own purposes
+func isChunked(data map[string]interface{}) bool {
+	if kind, _ := data[CodecTypeKey].(string); kind != "file" {
+		return false
+	}
+	_, ok := data[fileChunksKey].([]interface{})
+	return ok
+}
+
+// chunkPattern matches the names returned by chunkName, see isChunkName
+var chunkPattern = regexp.MustCompile(`^\.([^/]+)\.chunks/[0-9a-f]{16}/[0-9]{4}$`)
+
+// isChunkName checks if rel is a name returned by chunkName for the file at
+// name; manifests are secret data, so their chunk names can not be trusted to
+// stay next to the file
+func isChunkName(name, rel string) bool {
+	m := chunkPattern.FindStringSubmatch(rel)
+	return m != nil && m[1] == path.Base(name) && !strings.Contains(rel, "..")
+}
+
+// fileChunks returns the paths of the chunks of the chunked file at name with
+// data, nil for other secrets
+func (c *Client) fileChunks(name string, data map[string]interface{}) ([]string, error) {
+	if !isChunked(data) {
+		return nil, nil
+	}
+	var (
+		dir    = path.Dir(c.abspath(name))
+		chunks = data[fileChunksKey].([]interface{})
+		paths  = make([]string, len(chunks))
+	)
+	for i, chunk := range chunks {
+		rel, ok := chunk.(string)
+		if !ok || !isChunkName(name, rel) {
+			return nil, fmt.Errorf("vc: %s: invalid chunk %d", name, i)
+		}
+		paths[i] = path.Join(dir, rel)
+	}
+	return paths, nil
+}
+
+// chunkName returns the name of chunk i of generation gen of the file at path,
+// relative to the directory of the file
+func chunkName(name, gen string, i int) string {
+	return fmt.Sprintf(".%s.chunks/%s/%04d", path.Base(name), gen, i)
+}
+
+// chunkGeneration returns the generation of chunks for the stored contents b;
+// chunks of different contents never share a name, so rewriting a file does
+// not touch the chunks the current manifest refers to
+func chunkGeneration(b []byte) string {
+	return sha256Hex(b)[:16]
+}
+
+// isChunkPath checks if the relative path is in a chunk directory
+func isChunkPath(rel string) bool {
+	for _, part := range strings.Split(rel, "/") {
+		if strings.HasPrefix(part, ".") && strings.HasSuffix(part, ".chunks") {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeContents base64 encodes contents, broken in lines
+func encodeContents(b []byte) string {
+	out := new(bytes.Buffer)
+	var breaker lineBreaker
+	breaker.out = out
+
+	b64 := base64.NewEncoder(base64.StdEncoding, &breaker)
+	b64.Write(b)
+	b64.Close()
+	breaker.Close()
+
+	return out.String()
+}
+
+// decodeContents decodes the base64 encoded contents key of data
+func decodeContents(data map[string]interface{}) ([]byte, error) {
+	contents, ok := data[fileContentsKey].(string)
+	if !ok {
+		return nil, ErrFileContentsMissing
+	}
+	return base64.StdEncoding.DecodeString(contents)
+}
+
+// FileContents returns the contents of a file secret, decompressed and
+// verified; chunked files can only be read by the Client
+func FileContents(data map[string]interface{}) ([]byte, error) {
+	// The codec is passed the data without type marker
+	if _, ok := data[fileChunksKey].([]interface{}); ok {
+		return nil, ErrFileChunked
+	}
+	b, err := decodeContents(data)
+	if err != nil {
+		return nil, err
+	}
+	return decodeFile(b, data)
+}
+
+// decodeFile decompresses the (reassembled) contents of a file secret and
+// verifies its size and checksum; files stored by older versions of vc have
+// neither
+func decodeFile(b []byte, data map[string]interface{}) ([]byte, error) {
+	size := int64(-1)
+	if value, ok := data[fileSizeKey]; ok {
+		var err error
+		if size, err = jsonInt(value); err != nil {
+			return nil, fmt.Errorf("invalid size: %v", err)
+		}
+	}
+
+	// Compressed files are always stored with their size, which limits the
+	// decompressed data
+	encoding, _ := data[fileEncodingKey].(string)
+	if encoding != "" && size < 0 {
+		return nil, errors.New("compressed file has no size")
+	}
+	b, err := decompress(encoding, b, size)
+	if err != nil {
+		return nil, err
+	}
+	if size >= 0 && size != int64(len(b)) {
+		return nil, fmt.Errorf("expected %d bytes, got %d", size, len(b))
+	}
+	if sum, ok := data[fileSHA256Key]; ok {
+		if err = verifySHA256(b, sum); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// readFile returns the contents of the file secret at name with data;
+// chunked files are reassembled and verified
+func (c *Client) readFile(name string, data map[string]interface{}) ([]byte, error) {
+	b, err := c.readStored(name, data)
+	if err != nil {
+		return nil, err
+	}
+	if b, err = decodeFile(b, data); err != nil {
+		return nil, fmt.Errorf("vc: %s: %v", name, err)
+	}
+	return b, nil
+}
+
+// readStored returns the stored, possibly compressed, contents of the file
+// secret at name with data; chunks are reassembled and their checksums
+// verified
+func (c *Client) readStored(name string, data map[string]interface{}) ([]byte, error) {
+	if !isChunked(data) {
+		return decodeContents(data)
+	}
+
+	chunks, err := c.fileChunks(name, data)
+	if err != nil {
+		return nil, err
+	}
+	buf := new(bytes.Buffer)
+	for _, chunk := range chunks {
+		secret, err := c.Read(chunk)
+		if err != nil {
+			return nil, err
+		} else if secret == nil {
+			return nil, fmt.Errorf("vc: %s: chunk %s is missing", name, chunk)
+		}
+		b, err := decodeContents(secret.Data)
+		if err != nil {
+			return nil, fmt.Errorf("vc: %s: chunk %s: %v", name, chunk, err)
+		}
+		if err = verifySHA256(b, secret.Data[fileSHA256Key]); err != nil {
+			return nil, fmt.Errorf("vc: %s: chunk %s: %v", name, chunk, err)
+		}
+		buf.Write(b)
+	}
+	return buf.Bytes(), nil
+}
+
+// writeFile stores b as file secret at name, with the metadata of info (if
+// any) and compressed with encoding (if any); if the stored data is larger
+// than chunkSize, it is stored in chunks next to a manifest secret at name.
+func (c *Client) writeFile(name string, b []byte, info *fileInfo, encoding string, chunkSize int) error {
+	if info == nil {
+		info = new(fileInfo)
+	}
+	info.size = int64(len(b))
+	info.sum = sha256Hex(b)
+
+	b, err := compress(encoding, b)
+	if err != nil {
+		return err
+	}
+
+	data := map[string]interface{}{
+		CodecTypeKey: "file",
+	}
+	if encoding != "" {
+		data[fileEncodingKey] = encoding
+	}
+	info.update(data)
+	return c.writeStored(name, b, data, chunkSize)
+}
+
+// writeStored stores the (compressed) contents b of a file secret at name,
+// with the metadata in data. Chunks of the previous version of the file that
+// are no longer used are removed.
+func (c *Client) writeStored(name string, b []byte, data map[string]interface{}, chunkSize int) error {
+	name = c.abspath(name)
+	if chunkSize <= 0 {
+		chunkSize = fileChunkSize
+	}
+
+	var stale []string
+	if secret, err := c.Read(name); err != nil {
+		return err
+	} else if secret != nil {
+		// Invalid chunks of the previous version are left alone, the new
+		// manifest no longer refers to them
+		if stale, err = c.fileChunks(name, secret.Data); err != nil {
+			Debugf("file: not removing stale chunks: %v", err)
+		}
+	}
+
+	delete(data, fileContentsKey)
+	delete(data, fileChunksKey)
+	if len(b) <= chunkSize {
+		data[fileContentsKey] = encodeContents(b)
+	} else {
+		// Chunks are written under a new generation before the manifest, so the
+		// current manifest keeps referring to its own chunks if writing fails
+		var (
+			chunks []interface{}
+			gen    = chunkGeneration(b)
+		)
+		for i := 0; i*chunkSize < len(b); i++ {
+			end := (i + 1) * chunkSize
+			if end > len(b) {
+				end = len(b)
+			}
+			part := b[i*chunkSize : end]
+			rel := chunkName(name, gen, i)
+			Debugf("file: write chunk %s (%d bytes)", rel, len(part))
+			if _, err := c.Write(path.Join(path.Dir(name), rel), map[string]interface{}{
+				fileContentsKey: encodeContents(part),
+				fileSHA256Key:   sha256Hex(part),
+			}); err != nil {
+				return err
+			}
+			chunks = append(chunks, rel)
+		}
+		data[fileChunksKey] = chunks
+	}
+
+	if _, err := c.Write(name, data); err != nil {
+		return err
+	}
+
+	used := make(map[string]bool)
+	if chunks, ok := data[fileChunksKey].([]interface{}); ok {
+		for _, chunk := range chunks {
+			used[path.Join(path.Dir(name), chunk.(string))] = true
+		}
+	}
+	for _, chunk := range stale {
+		if !used[chunk] {
+			Debugf("file: remove stale chunk %s", chunk)
+			if _, err := c.Delete(chunk); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// verifySHA256 compares the checksum of b with the hex encoded sum
+func verifySHA256(b []byte, sum interface{}) error {
+	want, ok := sum.(string)
+	if !ok {
+		return errors.New("checksum missing")
+	}
+	if got := sha256Hex(b); got != want {
+		return fmt.Errorf("checksum mismatch, expected %s, got %s", want, got)
+	}
+	return nil
+}
+
+// jsonInt converts a number as decoded from Vault to an integer
+func jsonInt(value interface{}) (int64, error) {
+	switch value := value.(type) {
+	case json.Number:
+		return value.Int64()
+	case int:
+		return int64(value), nil
+	case int64:
+		return value, nil
+	case float64:
+		return int64(value), nil
+	default:
+		return 0, fmt.Errorf("unexpected %T", value)
+	}
+}
+
+// copySecret writes the secret data read from src to dst; chunked files are
+// verified and stored with their own chunks next to dst, as is
+func (c *Client) copySecret(src, dst string, data map[string]interface{}) error {
+	if !isChunked(data) {
+		_, err := c.Write(dst, data)
+		return err
+	}
+	b, err := c.readStored(src, data)
+	if err != nil {
+		return err
+	}
+	if _, err = decodeFile(b, data); err != nil {
+		return fmt.Errorf("vc: %s: %v", src, err)
+	}
+
+	manifest := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		manifest[key] = value
+	}
+	n := len(data[fileChunksKey].([]interface{}))
+	if n == 0 {
+		n = 1
+	}
+	return c.writeStored(dst, b, manifest, (len(b)+n-1)/n)
+}
+
+// deleteFile removes the file secret at name with data, including its chunks;
+// other secrets are removed as is
+func (c *Client) deleteFile(name string, data map[string]interface{}) error {
+	chunks, err := c.fileChunks(name, data)
+	if err != nil {
+		return err
+	}
+	if _, err = c.Delete(c.abspath(name)); err != nil {
+		return err
+	}
+	for _, chunk := range chunks {
+		if _, err = c.Delete(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
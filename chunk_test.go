@@ -0,0 +1,315 @@
+package vc
+
+import (
+	"bytes"
+	"errors"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+)
+
+func TestClientWriteFile(t *testing.T) {
+	_, client := testMemoryClient()
+
+	for _, name := range []string{"secret/files/small", "kv/files/small"} {
+		if err := client.writeFile(name, []byte("small"), nil, "", 8); err != nil {
+			t.Fatal(err)
+		}
+		secret, err := client.Read(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if isChunked(secret.Data) {
+			t.Fatalf("%s: expected single secret file, got %+v", name, secret.Data)
+		}
+		if b, err := client.readFile(name, secret.Data); err != nil {
+			t.Fatal(err)
+		} else if string(b) != "small" {
+			t.Fatalf("%s: expected %q, got %q", name, "small", b)
+		}
+	}
+
+	large := bytes.Repeat([]byte("0123456789"), 3)
+	for _, name := range []string{"secret/files/large", "kv/files/large"} {
+		if err := client.writeFile(name, large, nil, "", 8); err != nil {
+			t.Fatal(err)
+		}
+		secret, err := client.Read(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !isChunked(secret.Data) {
+			t.Fatalf("%s: expected chunked file, got %+v", name, secret.Data)
+		}
+		if chunks := secret.Data[fileChunksKey].([]interface{}); len(chunks) != 4 {
+			t.Fatalf("%s: expected 4 chunks, got %d", name, len(chunks))
+		}
+		if b, err := client.readFile(name, secret.Data); err != nil {
+			t.Fatal(err)
+		} else if !bytes.Equal(b, large) {
+			t.Fatalf("%s: expected %q, got %q", name, large, b)
+		}
+
+		// Rewrites remove the chunks that are no longer used
+		old := secret.Data[fileChunksKey].([]interface{})
+		if err = client.writeFile(name, large[:10], nil, "", 8); err != nil {
+			t.Fatal(err)
+		}
+		if secret, err = client.Read(name); err != nil {
+			t.Fatal(err)
+		}
+		current := secret.Data[fileChunksKey].([]interface{})
+		if len(current) != 2 {
+			t.Fatalf("%s: expected 2 chunks, got %d", name, len(current))
+		}
+		for _, chunks := range [][]interface{}{old, current} {
+			for _, rel := range chunks {
+				chunk, err := client.Read(path.Join(path.Dir(name), rel.(string)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if want := chunks[0] == current[0]; (chunk != nil) != want {
+					t.Fatalf("%s: expected chunk %s to exist: %t", name, rel, want)
+				}
+			}
+		}
+	}
+
+	// Corrupt a chunk
+	secret, err := client.Read("secret/files/large")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testWrite(t, client, path.Join("secret/files", secret.Data[fileChunksKey].([]interface{})[1].(string)), map[string]interface{}{
+		fileContentsKey: encodeContents([]byte("corrupt")),
+		fileSHA256Key:   sha256Hex([]byte("01234567")),
+	})
+	if _, err = client.readFile("secret/files/large", secret.Data); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected checksum mismatch, got %v", err)
+	}
+}
+
+func TestClientWriteFileCompressed(t *testing.T) {
+	_, client := testMemoryClient()
+
+	data := bytes.Repeat([]byte("0123456789"), 100)
+	for _, encoding := range []string{encodingGzip, encodingZstd} {
+		for _, chunkSize := range []int{0, 8} {
+			name := "kv/files/" + encoding
+			if err := client.writeFile(name, data, nil, encoding, chunkSize); err != nil {
+				t.Fatal(err)
+			}
+			secret, err := client.Read(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if secret.Data[fileEncodingKey] != encoding || isChunked(secret.Data) != (chunkSize == 8) {
+				t.Fatalf("%s: unexpected data %+v", name, secret.Data)
+			}
+			if b, err := client.readFile(name, secret.Data); err != nil {
+				t.Fatal(err)
+			} else if !bytes.Equal(b, data) {
+				t.Fatalf("%s: expected %q, got %q", name, data, b)
+			}
+			if info := newFileInfo(secret.Data); info.size != int64(len(data)) {
+				t.Fatalf("%s: expected size %d, got %d", name, len(data), info.size)
+			}
+		}
+	}
+}
+
+// failingWriteBackend fails to write paths containing fail
+type failingWriteBackend struct {
+	*MemoryBackend
+	fail string
+}
+
+func (b *failingWriteBackend) Write(path string, data map[string]interface{}) (*api.Secret, error) {
+	if b.fail != "" && strings.Contains(path, b.fail) {
+		return nil, errors.New("write failed")
+	}
+	return b.MemoryBackend.Write(path, data)
+}
+
+func TestClientWriteFileFailure(t *testing.T) {
+	var (
+		backend = &failingWriteBackend{MemoryBackend: testBackend()}
+		client  = NewBackendClient(backend)
+		large   = bytes.Repeat([]byte("0123456789"), 3)
+	)
+	if err := client.writeFile("kv/files/large", large, nil, "", 8); err != nil {
+		t.Fatal(err)
+	}
+
+	// Rewriting fails halfway, the file is still intact
+	backend.fail = "/0002"
+	if err := client.writeFile("kv/files/large", bytes.Repeat([]byte("abcdefghij"), 3), nil, "", 8); err == nil {
+		t.Fatal("expected write to fail")
+	}
+	secret, err := client.Read("kv/files/large")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err := client.readFile("kv/files/large", secret.Data); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(b, large) {
+		t.Fatalf("expected %q, got %q", large, b)
+	}
+}
+
+// testSecretPaths lists all secrets below root, including chunks
+func testSecretPaths(t *testing.T, client *Client, root string) []string {
+	t.Helper()
+	paths, err := client.secretPaths(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return paths
+}
+
+func TestChunkedFileCommands(t *testing.T) {
+	// Removed secrets are still listed on KV version 2 mounts, so use version 1
+	_, client := testMemoryClient()
+	large := bytes.Repeat([]byte("0123456789"), 3)
+	if err := client.writeFile("secret/a/bigfile", large, nil, "", 8); err != nil {
+		t.Fatal(err)
+	}
+
+	verify := func(name string) {
+		t.Helper()
+		secret, err := client.Read(name)
+		if err != nil {
+			t.Fatal(err)
+		} else if secret == nil {
+			t.Fatalf("%s: not found", name)
+		}
+		if !isChunked(secret.Data) {
+			t.Fatalf("%s: expected chunked file", name)
+		}
+		if b, err := client.readFile(name, secret.Data); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		} else if !bytes.Equal(b, large) {
+			t.Fatalf("%s: expected %q, got %q", name, large, b)
+		}
+	}
+
+	for _, test := range []testCommand{
+		testCommand{
+			Factory: CopyCommandFactory,
+			Args:    []string{"secret/a/bigfile", "secret/b/copy"},
+			Code:    Success,
+		},
+		testCommand{
+			Factory: CopyCommandFactory,
+			Args:    []string{"-R", "secret/a", "secret/c"},
+			Code:    Success,
+		},
+		testCommand{
+			Factory: MoveCommandFactory,
+			Args:    []string{"secret/b/copy", "secret/d/moved"},
+			Code:    Success,
+		},
+	} {
+		test.Client = client
+		testCommandRun(t, test)
+	}
+	verify("secret/a/bigfile")
+	verify("secret/c/bigfile")
+	verify("secret/d/moved")
+	if paths := testSecretPaths(t, client, "secret/b"); len(paths) != 0 {
+		t.Fatalf("expected no secrets left after move, got %q", paths)
+	}
+	if paths := testSecretPaths(t, client, "secret/c"); len(paths) != 5 {
+		t.Fatalf("expected manifest and 4 chunks after recursive copy, got %q", paths)
+	}
+
+	testCommandRun(t, testCommand{
+		Factory: DeleteCommandFactory,
+		Args:    []string{"secret/d/moved"},
+		Code:    Success,
+		Client:  client,
+	})
+	if paths := testSecretPaths(t, client, "secret/d"); len(paths) != 0 {
+		t.Fatalf("expected no secrets left after rm, got %q", paths)
+	}
+}
+
+func TestChunkedFileUntrusted(t *testing.T) {
+	_, client := testMemoryClient()
+	testWrite(t, client, "secret/app/victim", map[string]interface{}{"key": "value"})
+	testWrite(t, client, "secret/other/db", map[string]interface{}{fileContentsKey: encodeContents([]byte("password"))})
+
+	// Secrets that are not files may have a chunks key of their own
+	testWrite(t, client, "secret/app/list", map[string]interface{}{fileChunksKey: []interface{}{"victim"}})
+	testCommandRun(t, testCommand{
+		Factory: DeleteCommandFactory,
+		Args:    []string{"secret/app/list"},
+		Code:    Success,
+		Client:  client,
+	})
+	if secret, err := client.Read("secret/app/victim"); err != nil {
+		t.Fatal(err)
+	} else if secret == nil {
+		t.Fatal("expected secret/app/victim to be kept")
+	}
+
+	// Chunks outside of the chunk directory of the file are rejected
+	for _, chunk := range []string{"../../secret/other/db", "victim", ".f.chunks/0123456789abcdef/../../victim", ".g.chunks/0123456789abcdef/0000"} {
+		data := map[string]interface{}{
+			CodecTypeKey:  "file",
+			fileChunksKey: []interface{}{chunk},
+		}
+		testWrite(t, client, "secret/app/f", data)
+		if _, err := client.readFile("secret/app/f", data); err == nil || !strings.Contains(err.Error(), "invalid chunk") {
+			t.Fatalf("%s: expected invalid chunk, got %v", chunk, err)
+		}
+		if err := client.deleteFile("secret/app/f", data); err == nil {
+			t.Fatalf("%s: expected delete to fail", chunk)
+		}
+		if err := client.writeFile("secret/app/f", []byte("small"), nil, "", 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"secret/app/victim", "secret/other/db"} {
+		if secret, err := client.Read(name); err != nil {
+			t.Fatal(err)
+		} else if secret == nil {
+			t.Fatalf("expected %s to be kept", name)
+		}
+	}
+}
+
+func TestChunkedFileVersions(t *testing.T) {
+	ReplaceCodec("file", new(testCodec))
+
+	_, client := testMemoryClient()
+	for _, b := range [][]byte{bytes.Repeat([]byte("0123456789"), 3), bytes.Repeat([]byte("abcdefghij"), 3)} {
+		if err := client.writeFile("kv/files/large", b, nil, "", 8); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The chunks of version 1 were removed when writing version 2
+	for _, test := range []testCommand{
+		testCommand{
+			Factory: CatCommandFactory,
+			Args:    []string{"-V", "1", "kv/files/large"},
+			Code:    SyntaxError,
+		},
+		testCommand{
+			Factory: CatCommandFactory,
+			Args:    []string{"-V", "2", "kv/files/large"},
+			Code:    Success,
+		},
+		testCommand{
+			Factory: CatCommandFactory,
+			Args:    []string{"kv/files/large"},
+			Code:    Success,
+		},
+	} {
+		test.Client = client
+		testCommandRun(t, test)
+	}
+}
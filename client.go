@@ -1,12 +1,11 @@
 package vc
 
 import (
-	"errors"
-	"fmt"
+	"encoding/json"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/chzyer/readline"
@@ -14,14 +13,38 @@ import (
 )
 
 const (
-	genericType  = "generic"
-	mountRefresh = time.Minute
+	genericType = "generic"
+	kvType      = "kv"
 )
 
+// mountRefresh is how long the mounts lookup is cached
+var mountRefresh = time.Minute
+
 func isPermissionDenied(err error) bool {
 	return strings.Contains(err.Error(), "* permission denied")
 }
 
+// isSecretMount returns true if the mount is a (generic) key/value secrets engine
+func isSecretMount(mount *api.MountOutput) bool {
+	return mount.Type == genericType || mount.Type == kvType
+}
+
+// kvVersion returns the version of the key/value secrets engine of the mount
+func kvVersion(mount *api.MountOutput) int {
+	if mount != nil && mount.Type == kvType && mount.Options["version"] == "2" {
+		return 2
+	}
+	return 1
+}
+
+// mountType returns the type of a mount, with the version for KV version 2
+func mountType(mount *api.MountOutput) string {
+	if kvVersion(mount) == 2 {
+		return mount.Type + "2"
+	}
+	return mount.Type
+}
+
 type completionFilter func(os.FileInfo) bool
 
 func isAny(i os.FileInfo) bool {
@@ -45,12 +68,16 @@ func matchesFilters(i os.FileInfo, filters ...completionFilter) bool {
 type Client struct {
 	*api.Client
 
+	// Backend for storage operations, defaults to the Vault API
+	Backend Backend
+
 	// Path we are operating on, defaults to the root
 	Path string
 
-	// cachedMounts is a cached mounts lookup
+	// cachedMounts is a cached mounts lookup, guarded by mountsMutex
 	cachedMounts     map[string]*api.MountOutput
 	cachedMountsTime time.Time
+	mountsMutex      sync.Mutex
 }
 
 // NewClient builds a new Client
@@ -64,6 +91,22 @@ func NewClient(config *api.Config) (*Client, error) {
 	return c, err
 }
 
+// NewBackendClient builds a new Client operating on backend
+func NewBackendClient(backend Backend) *Client {
+	return &Client{
+		Backend: backend,
+		Path:    "/",
+	}
+}
+
+// backend returns our Backend
+func (c *Client) backend() Backend {
+	if c.Backend == nil {
+		return apiBackend{c.Client}
+	}
+	return c.Backend
+}
+
 // abspath resolves the absolute path
 func (c *Client) abspath(path string) string {
 	if filepath.IsAbs(path) {
@@ -72,10 +115,14 @@ func (c *Client) abspath(path string) string {
 	return filepath.Clean(filepath.Join(c.Path, path))
 }
 
-// mounts updates Client.cachedMounts if applicable
+// mounts updates Client.cachedMounts if applicable; the mutex is held during
+// the refresh, so concurrent callers wait for it instead of refreshing again
 func (c *Client) mounts() (mounts map[string]*api.MountOutput, err error) {
+	c.mountsMutex.Lock()
+	defer c.mountsMutex.Unlock()
+
 	if time.Now().Add(-mountRefresh).After(c.cachedMountsTime) {
-		mounts, err = c.Sys().ListMounts()
+		mounts, err = c.backend().ListMounts()
 		if err == nil {
 			c.cachedMounts = mounts
 			c.cachedMountsTime = time.Now()
@@ -86,6 +133,123 @@ func (c *Client) mounts() (mounts map[string]*api.MountOutput, err error) {
 	return
 }
 
+// mountFor looks up the mount that contains path
+func (c *Client) mountFor(path string) (name string, mount *api.MountOutput, err error) {
+	var mounts map[string]*api.MountOutput
+	if mounts, err = c.mounts(); err != nil {
+		if isPermissionDenied(err) {
+			// We can't tell, assume version 1
+			err = nil
+		}
+		return
+	}
+
+	path = strings.TrimLeft(path, "/") + "/"
+	for prefix, candidate := range mounts {
+		if strings.HasPrefix(path, prefix) && len(prefix) > len(name) {
+			name, mount = prefix, candidate
+		}
+	}
+	return
+}
+
+// kvPath resolves the API path for a secret; for KV version 2 mounts the
+// prefix (data or metadata) is inserted after the mount path
+func (c *Client) kvPath(path, prefix string) (string, int, error) {
+	path = strings.TrimLeft(c.abspath(path), "/")
+
+	name, mount, err := c.mountFor(path)
+	if err != nil {
+		return "", 0, err
+	}
+
+	version := kvVersion(mount)
+	if version == 2 {
+		path = name + prefix + "/" + strings.TrimPrefix(path+"/", name)
+		path = strings.TrimRight(path, "/")
+	}
+	Debugf("kv: path %q (version %d)", path, version)
+	return path, version, nil
+}
+
+// kvUnwrap strips the envelope KV version 2 puts around the secret data
+func kvUnwrap(secret *api.Secret) *api.Secret {
+	if secret == nil || secret.Data == nil {
+		return nil
+	}
+	data, ok := secret.Data["data"].(map[string]interface{})
+	if !ok {
+		// Deleted or destroyed secret
+		return nil
+	}
+	secret.Data = data
+	return secret
+}
+
+// Read a secret
+func (c *Client) Read(path string) (*api.Secret, error) {
+	path, version, err := c.kvPath(path, "data")
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := c.backend().Read(path)
+	if err != nil || version == 1 {
+		return secret, err
+	}
+	return kvUnwrap(secret), nil
+}
+
+// Write a secret
+func (c *Client) Write(path string, data map[string]interface{}) (*api.Secret, error) {
+	path, version, err := c.kvPath(path, "data")
+	if err != nil {
+		return nil, err
+	}
+
+	if version == 2 {
+		data = map[string]interface{}{
+			"data": data,
+		}
+	}
+	return c.backend().Write(path, data)
+}
+
+// Delete a secret; on KV version 2 mounts, the latest version is deleted
+func (c *Client) Delete(path string) (*api.Secret, error) {
+	path, _, err := c.kvPath(path, "data")
+	if err != nil {
+		return nil, err
+	}
+	return c.backend().Delete(path)
+}
+
+// List secrets
+func (c *Client) List(path string) (*api.Secret, error) {
+	path, _, err := c.kvPath(path, "metadata")
+	if err != nil {
+		return nil, err
+	}
+	return c.backend().List(path)
+}
+
+// Capabilities looks up the capabilities of our token on a secret, or on a
+// directory if path ends with a slash
+func (c *Client) Capabilities(path string) ([]string, error) {
+	prefix, dir := "data", strings.HasSuffix(path, "/")
+	if dir {
+		prefix = "metadata"
+	}
+	path, _, err := c.kvPath(path, prefix)
+	if err != nil {
+		return nil, err
+	}
+	if dir {
+		path += "/"
+	}
+	return c.backend().CapabilitiesSelf(path)
+}
+
 // Complete returns completer suggestions
 func (c *Client) Complete(filters ...completionFilter) readline.DynamicCompleteFunc {
 	return func(line string) []string {
@@ -159,7 +323,7 @@ func (c *Client) Stat(path string) (os.FileInfo, error) {
 	}
 
 	// Check if the path is a file
-	secret, err := c.Logical().Read(strings.TrimLeft(path, "/"))
+	secret, err := c.Read(path)
 	// Directories would get a permission denied error on Read(). So ignore it.
 	if err != nil && !isPermissionDenied(err) {
 		return nil, err
@@ -169,6 +333,8 @@ func (c *Client) Stat(path string) (os.FileInfo, error) {
 			Secret: secret,
 			Path:   filepath.Clean(path),
 			Key:    filepath.Base(path),
+			client: c,
+			data:   secret.Data,
 		}, nil
 	}
 
@@ -177,7 +343,7 @@ func (c *Client) Stat(path string) (os.FileInfo, error) {
 	if dir != "/" {
 		// All folders in / are mounts, so skip this unless we're not in the root
 		Debugf("stat: list %q", strings.TrimLeft(path, "/"))
-		secret, err = c.Logical().List(strings.TrimLeft(path, "/"))
+		secret, err = c.List(path)
 		if err != nil {
 			return nil, err
 		}
@@ -229,7 +395,7 @@ func (c *Client) ReadDir(path string) ([]os.FileInfo, error) {
 	}
 	for name, mount := range mounts {
 		name = "/" + strings.TrimRight(name, "/")
-		if mount.Type != genericType {
+		if !isSecretMount(mount) {
 			continue
 		}
 		var (
@@ -252,8 +418,11 @@ func (c *Client) ReadDir(path string) ([]os.FileInfo, error) {
 		}
 	}
 
-	// Check secrets
-	secret, err := c.Logical().List(strings.TrimLeft(path, "/"))
+	// Check secrets, all items in the root are mounts
+	if path == "/" {
+		return infos, nil
+	}
+	secret, err := c.List(path)
 	if err != nil {
 		return nil, err
 	}
@@ -263,6 +432,7 @@ func (c *Client) ReadDir(path string) ([]os.FileInfo, error) {
 				Secret: secret,
 				Path:   filepath.Clean(filepath.Join(strings.TrimRight(path, "/"), key.(string))),
 				Key:    key.(string),
+				client: c,
 			})
 		}
 	}
@@ -270,63 +440,6 @@ func (c *Client) ReadDir(path string) ([]os.FileInfo, error) {
 	return infos, nil
 }
 
-func globExpression(pattern string) string {
-	return strings.Replace(strings.Replace(pattern, "?", ".", -1), "*", ".*", -1)
-}
-
-func (c *Client) isGlob(pattern string) bool {
-	return strings.ContainsAny(pattern, "*?")
-}
-
-// Glob is a shortcut to list generic secrets and mounts by glob pattern. The
-// wildcards "*" and "?" are supported. Currently only globbing the base of the
-// path is supported, globbing on directory names is not.
-func (c *Client) Glob(pattern string) ([]os.FileInfo, error) {
-	Debugf("glob: %q", pattern)
-
-	// Fast path, no globbing required
-	if !c.isGlob(pattern) {
-		info, err := c.Stat(pattern)
-		return []os.FileInfo{info}, err
-	}
-
-	Debugf("glob abs: %q", c.abspath(pattern))
-	dir, base := filepath.Split(c.abspath(pattern))
-	if strings.ContainsAny(dir, "*?") {
-		return nil, errors.New("directory globbing not supported")
-	}
-	if dir != "/" {
-		dir = strings.TrimRight(dir, "/")
-	}
-
-	var (
-		filter *regexp.Regexp
-		err    error
-	)
-	if dir == "/" {
-		filter, err = regexp.Compile(`^/` + globExpression(base))
-	} else {
-		filter, err = regexp.Compile(fmt.Sprintf("^%s/%s$", regexp.QuoteMeta(dir), globExpression(base)))
-	}
-	if err != nil {
-		return nil, err
-	}
-
-	var infos []os.FileInfo
-	items, err := c.ReadDir(dir)
-	if err != nil {
-		return nil, err
-	}
-	for _, item := range items {
-		Debugf("filter: %q =~ %s", item.Name(), filter)
-		if filter.MatchString(item.Name()) {
-			infos = append(infos, item)
-		}
-	}
-
-	return infos, nil
-}
-
 // SetPath updates our working path
 func (c *Client) SetPath(path string) {
 	if !strings.HasPrefix(path, "/") {
@@ -358,21 +471,65 @@ func (i *mountInfo) ModTime() time.Time { return time.Time{} }
 func (i *mountInfo) IsDir() bool        { return true }
 func (i *mountInfo) Sys() interface{}   { return i.MountOutput }
 
-// secretInfo is a wrapper for api.Secret that implements os.FileInfo
+// secretInfo is a wrapper for api.Secret that implements os.FileInfo; the
+// size and modification time are looked up on first use, as listing a
+// directory does not return them
 type secretInfo struct {
 	*api.Secret
 	Path string
 	Key  string
+
+	client  *Client
+	data    map[string]interface{}
+	once    sync.Once
+	size    int64
+	modTime time.Time
 }
 
 func (i *secretInfo) Name() string { return i.Path }
-func (i *secretInfo) Size() int64  { return 0 }
+func (i *secretInfo) Size() int64  { i.stat(); return i.size }
 func (i *secretInfo) Mode() os.FileMode {
 	if i.IsDir() {
 		return 0755
 	}
 	return 0644
 }
-func (i *secretInfo) ModTime() time.Time { return time.Time{} }
+func (i *secretInfo) ModTime() time.Time { i.stat(); return i.modTime }
 func (i *secretInfo) IsDir() bool        { return strings.HasSuffix(i.Key, "/") }
 func (i *secretInfo) Sys() interface{}   { return i.Secret }
+
+// stat looks up the size and modification time of the secret, errors leave
+// them at their zero value
+func (i *secretInfo) stat() {
+	i.once.Do(func() {
+		if i.client == nil || i.IsDir() {
+			return
+		}
+		if i.data == nil {
+			secret, err := i.client.Read(i.Path)
+			if err != nil || secret == nil {
+				Debugf("stat: %s: %v", i.Path, err)
+				return
+			}
+			i.data = secret.Data
+		}
+		i.size, i.modTime, _ = i.client.secretStat(i.Path, i.data)
+	})
+}
+
+// secretStat returns the size, modification time and KV version 2 version of
+// the secret at path with data. Files have the size and modification time of
+// their metadata, other secrets the size of their JSON encoding; on KV version
+// 2 mounts the time of the current version is used.
+func (c *Client) secretStat(path string, data map[string]interface{}) (size int64, modTime time.Time, version int) {
+	if kind, _ := data[CodecTypeKey].(string); kind == "file" {
+		file := newFileInfo(data)
+		size, modTime = file.size, file.modTime
+	} else if b, err := json.Marshal(data); err == nil {
+		size = int64(len(b))
+	}
+	if meta, err := c.Metadata(path); err == nil && meta != nil {
+		version, modTime = meta.CurrentVersion, meta.UpdatedTime
+	}
+	return
+}
@@ -1,6 +1,13 @@
 package vc
 
-import "testing"
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
 
 func TestClientPath(t *testing.T) {
 	tests := []struct {
@@ -23,3 +30,182 @@ func TestClientPath(t *testing.T) {
 		}
 	}
 }
+
+func TestClientKVPath(t *testing.T) {
+	c := &Client{
+		Path: "/",
+		cachedMounts: map[string]*api.MountOutput{
+			"generic/": &api.MountOutput{Type: "generic"},
+			"kv1/":     &api.MountOutput{Type: "kv", Options: map[string]string{"version": "1"}},
+			"kv2/":     &api.MountOutput{Type: "kv", Options: map[string]string{"version": "2"}},
+			"kv2/sub/": &api.MountOutput{Type: "generic"},
+		},
+		cachedMountsTime: time.Now(),
+	}
+
+	tests := []struct {
+		Test    string
+		Prefix  string
+		Want    string
+		Version int
+	}{
+		{"generic/foo", "data", "generic/foo", 1},
+		{"/kv1/foo/bar", "data", "kv1/foo/bar", 1},
+		{"kv2/foo/bar", "data", "kv2/data/foo/bar", 2},
+		{"kv2/foo/", "metadata", "kv2/metadata/foo", 2},
+		{"kv2", "metadata", "kv2/metadata", 2},
+		{"kv2/sub/foo", "data", "kv2/sub/foo", 1},
+		{"kv2foo/bar", "data", "kv2foo/bar", 1},
+	}
+	for _, test := range tests {
+		path, version, err := c.kvPath(test.Test, test.Prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if path != test.Want || version != test.Version {
+			t.Fatalf("kvPath(%q, %q): expected %q (version %d), got %q (version %d)",
+				test.Test, test.Prefix, test.Want, test.Version, path, version)
+		}
+	}
+}
+
+func TestClientBackends(t *testing.T) {
+	_, memory := testMemoryClient()
+	_, server := testServerClient(t)
+	for name, c := range map[string]*Client{"memory": memory, "server": server} {
+		for _, mount := range []string{"secret", "kv"} {
+			testWrite(t, c, mount+"/app/db", map[string]interface{}{"password": "test"})
+			testWrite(t, c, mount+"/app/api", map[string]interface{}{"token": "test"})
+			testWrite(t, c, mount+"/top", map[string]interface{}{"key": "test"})
+
+			secret, err := c.Read(mount + "/app/db")
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if secret == nil || secret.Data["password"] != "test" {
+				t.Fatalf("%s: read %s/app/db: unexpected secret %+v", name, mount, secret)
+			}
+
+			if secret, err = c.Read(mount + "/app/missing"); err != nil || secret != nil {
+				t.Fatalf("%s: read %s/app/missing: expected nil, got %+v (%v)", name, mount, secret, err)
+			}
+
+			infos, err := c.ReadDir(mount)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			var names []string
+			for _, info := range infos {
+				names = append(names, info.Name())
+			}
+			if want := []string{"/" + mount + "/app", "/" + mount + "/top"}; !reflect.DeepEqual(names, want) {
+				t.Fatalf("%s: readdir %s: expected %v, got %v", name, mount, want, names)
+			}
+
+			info, err := c.Stat(mount + "/app")
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if !info.IsDir() {
+				t.Fatalf("%s: stat %s/app: expected directory", name, mount)
+			}
+
+			if _, err = c.Delete(mount + "/top"); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if secret, err = c.Read(mount + "/top"); err != nil || secret != nil {
+				t.Fatalf("%s: read %s/top after delete: expected nil, got %+v (%v)", name, mount, secret, err)
+			}
+		}
+
+		// Values are returned as decoded from JSON
+		for _, mount := range []string{"secret", "kv"} {
+			testWrite(t, c, mount+"/app/types", map[string]interface{}{
+				"int":   42,
+				"float": 0.5,
+				"bytes": []byte("raw"),
+				"time":  time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+				"list":  []int{1, 2},
+			})
+			secret, err := c.Read(mount + "/app/types")
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			want := map[string]interface{}{
+				"int":   json.Number("42"),
+				"float": json.Number("0.5"),
+				"bytes": "cmF3",
+				"time":  "2020-01-02T03:04:05Z",
+				"list":  []interface{}{json.Number("1"), json.Number("2")},
+			}
+			if !reflect.DeepEqual(secret.Data, want) {
+				t.Fatalf("%s: read %s/app/types: expected %#v, got %#v", name, mount, want, secret.Data)
+			}
+		}
+
+		// Versions
+		testWrite(t, c, "kv/app/db", map[string]interface{}{"password": "changed"})
+		meta, err := c.Metadata("kv/app/db")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if meta.CurrentVersion != 2 || len(meta.Versions) != 2 {
+			t.Fatalf("%s: metadata: expected 2 versions, got %+v", name, meta)
+		}
+		secret, err := c.ReadVersion("kv/app/db", 1)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if secret == nil || secret.Data["password"] != "test" {
+			t.Fatalf("%s: read version 1: unexpected secret %+v", name, secret)
+		}
+		if _, err = c.ReadVersion("secret/app/db", 1); err != ErrNotVersioned {
+			t.Fatalf("%s: read version on version 1 mount: expected %v, got %v", name, ErrNotVersioned, err)
+		}
+
+		// Capabilities
+		caps, err := c.Capabilities("kv/app/")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(caps, []string{"root"}) {
+			t.Fatalf("%s: capabilities: expected [root], got %v", name, caps)
+		}
+	}
+}
+
+func TestClientSecretInfo(t *testing.T) {
+	_, client := testMemoryClient()
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := client.writeFile("secret/app/conf", []byte("0123456789"), &fileInfo{modTime: mtime}, "", 0); err != nil {
+		t.Fatal(err)
+	}
+	testWrite(t, client, "secret/app/db", map[string]interface{}{"password": "test"})
+	testWrite(t, client, "kv/app/db", map[string]interface{}{"password": "test"})
+
+	for _, dir := range []string{"secret/app", "kv/app"} {
+		infos, err := client.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(infos) == 0 {
+			t.Fatalf("%s: expected secrets", dir)
+		}
+		for _, info := range infos {
+			var (
+				size    int64 = int64(len(`{"password":"test"}`))
+				modTime       = info.ModTime()
+			)
+			if info.Name() == "/secret/app/conf" {
+				size = 10
+				if !modTime.Equal(mtime) {
+					t.Errorf("%s: expected modification time %s, got %s", info.Name(), mtime, modTime)
+				}
+			} else if dir == "kv/app" && modTime.IsZero() {
+				t.Errorf("%s: expected modification time of the current version", info.Name())
+			}
+			if info.Size() != size {
+				t.Errorf("%s: expected size %d, got %d", info.Name(), size, info.Size())
+			}
+		}
+	}
+}
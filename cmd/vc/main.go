@@ -71,9 +71,11 @@ List secrets.
  Usage: vc [<options>] ls [<secret path>]
 
  Options:
-   -1	list in compact format
+   -1	list one entry per line
    -R	recursively list subdirectories encountered
+   -S	sort by size, largest first
    -l	list in long format
+   -t	sort by modification time, newest first
 
 
 Command mv
@@ -132,8 +134,14 @@ Only partial support is implemented for the magic __TYPE__ key which allows
 for typed values.
 
 Available types:
- file     Base64 encoded file in key "contents"
- json     Substructure is a key-value dictionary with json encoding
+ file        Base64 encoded file in key "contents", optionally gzip or zstd
+             compressed as given by key "encoding"
+ json        Substructure is a key-value dictionary with json encoding
+ toml        Substructure is a key-value dictionary with toml encoding
+ ini         Substructure is a key-value dictionary with ini encoding
+ dotenv      Substructure is a key-value dictionary in .env format
+ properties  Substructure is a key-value dictionary in Java properties format
+ pem         PEM encoded certificate, chain, CA and private key
 */
 package main
 
@@ -54,6 +54,12 @@ type Codec interface {
 	Unmarshaler
 }
 
+// Summarizer can describe api.Secret.Data in a human readable form; codecs may
+// implement it in addition to Codec
+type Summarizer interface {
+	Summary(path string, data map[string]interface{}) ([]byte, error)
+}
+
 // ReplaceCodec replaces or adds a named codec
 func ReplaceCodec(name string, c Codec) (exists bool) {
 	codecMutex.Lock()
@@ -0,0 +1,97 @@
+package vc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// File encodings
+const (
+	fileEncodingKey = "encoding"
+	encodingGzip    = "gzip"
+	encodingZstd    = "zstd"
+
+	// zstdWindowSize is the window size used by zstd.NewWriter
+	zstdWindowSize = 8 << 20
+)
+
+// compress b with encoding
+func compress(encoding string, b []byte) ([]byte, error) {
+	switch encoding {
+	case "":
+		return b, nil
+	case encodingGzip:
+		buf := new(bytes.Buffer)
+		w, err := gzip.NewWriterLevel(buf, gzip.BestCompression)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = w.Write(b); err != nil {
+			return nil, err
+		}
+		if err = w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case encodingZstd:
+		w, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedBestCompression))
+		if err != nil {
+			return nil, err
+		}
+		defer w.Close()
+		return w.EncodeAll(b, nil), nil
+	default:
+		return nil, fmt.Errorf("unsupported encoding %q", encoding)
+	}
+}
+
+// decompress b with encoding; the output is limited to size bytes, so a small
+// secret can not decompress into more data than it claims to hold
+func decompress(encoding string, b []byte, size int64) ([]byte, error) {
+	if encoding != "" && size < 0 {
+		return nil, fmt.Errorf("invalid size %d", size)
+	}
+
+	var out []byte
+	switch encoding {
+	case "":
+		return b, nil
+	case encodingGzip:
+		r, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		if out, err = ioutil.ReadAll(io.LimitReader(r, size+1)); err != nil {
+			return nil, err
+		}
+	case encodingZstd:
+		// The limit also applies to the window, which is at most the default
+		// window size of the encoder
+		limit := uint64(size) + 1
+		if limit < zstdWindowSize {
+			limit = zstdWindowSize
+		}
+		r, err := zstd.NewReader(nil, zstd.WithDecoderMaxMemory(limit))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		if out, err = r.DecodeAll(b, nil); err == zstd.ErrDecoderSizeExceeded {
+			return nil, fmt.Errorf("decompressed data exceeds %d bytes", size)
+		} else if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported encoding %q", encoding)
+	}
+	if int64(len(out)) > size {
+		return nil, fmt.Errorf("decompressed data exceeds %d bytes", size)
+	}
+	return out, nil
+}
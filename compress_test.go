@@ -0,0 +1,45 @@
+package vc
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCompress(t *testing.T) {
+	data := bytes.Repeat([]byte("compressible "), 100)
+	for _, encoding := range []string{"", encodingGzip, encodingZstd} {
+		b, err := compress(encoding, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if encoding != "" && len(b) >= len(data) {
+			t.Fatalf("%s: expected less than %d bytes, got %d", encoding, len(data), len(b))
+		}
+		if encoding != "" {
+			if _, err = decompress(encoding, b, int64(len(data))-1); err == nil {
+				t.Fatalf("%s: expected error for data exceeding the size", encoding)
+			}
+		}
+		if b, err = decompress(encoding, b, int64(len(data))); err != nil {
+			t.Fatal(err)
+		} else if !bytes.Equal(b, data) {
+			t.Fatalf("%s: round trip changed data", encoding)
+		}
+	}
+
+	// A small secret can not decompress to more than its size
+	bomb := make([]byte, 64<<20)
+	for _, encoding := range []string{encodingGzip, encodingZstd} {
+		b, err := compress(encoding, bomb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = decompress(encoding, b, 1024); err == nil || !strings.Contains(err.Error(), "exceeds") {
+			t.Fatalf("%s: expected size exceeded, got %v", encoding, err)
+		}
+	}
+	if _, err := compress("lzw", data); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -12,34 +12,52 @@ import (
 // CopyCommand can display (structured) secrets
 type CopyCommand struct {
 	baseCommand
-	fs    *flag.FlagSet
-	force bool
+	fs      *flag.FlagSet
+	force   bool
+	recurse bool
 }
 
 func (cmd *CopyCommand) Help() string {
-	return "Usage: vc [<options>] cp <source secret> <target secret>\n\nOptions:\n" + defaults(cmd.fs)
+	return "Usage: vc [<options>] cp <source secret> <target secret>\n" +
+		"       vc [<options>] cp <source secret> [... <source secret>] <target directory>/\n\nOptions:\n" + defaults(cmd.fs)
 }
 
 func (cmd *CopyCommand) Run(args []string) int {
 	if err := cmd.fs.Parse(args); err != nil {
 		return SyntaxError
 	}
-	if args = cmd.fs.Args(); len(args) != 2 {
+	if args = cmd.fs.Args(); len(args) < 2 {
 		return Help
 	}
 
-	if args[0] == args[1] {
-		return Success
-	}
-
 	client, err := cmd.Client()
 	if err != nil {
 		cmd.ui.Error(err.Error())
 		return ClientError
 	}
 
+	sources, target := args[:len(args)-1], args[len(args)-1]
+	if sources, err = cmd.globs(client, sources); err != nil {
+		cmd.ui.Error(fmt.Sprintf("error: %v", err))
+		return SyntaxError
+	}
+
+	// Multiple sources or a target ending in a slash; copy into directory
+	if len(sources) > 1 || strings.HasSuffix(target, "/") {
+		return cmd.copyInto(client, sources, target, cmd.force, cmd.recurse, false)
+	}
+
+	args = []string{sources[0], target}
+	if args[0] == args[1] {
+		return Success
+	}
+
+	if cmd.recurse {
+		return cmd.copyTree(client, args[0], args[1], cmd.force, false)
+	}
+
 	// Read secret at old path
-	secret, err := client.Logical().Read(strings.TrimLeft(args[0], "/"))
+	secret, err := client.Read(args[0])
 	if err != nil {
 		cmd.ui.Error(err.Error())
 		return ServerError
@@ -51,7 +69,7 @@ func (cmd *CopyCommand) Run(args []string) int {
 
 	// Check if secret at new path exists, unless force is enabled
 	if !cmd.force {
-		oldSecret, oerr := client.Logical().Read(strings.TrimLeft(args[1], "/"))
+		oldSecret, oerr := client.Read(args[1])
 		if oerr != nil {
 			cmd.ui.Error(oerr.Error())
 			return SyntaxError
@@ -67,8 +85,8 @@ func (cmd *CopyCommand) Run(args []string) int {
 		}
 	}
 
-	// Write secret at new path
-	if _, err = client.Logical().Write(strings.TrimLeft(args[1], "/"), secret.Data); err != nil {
+	// Write secret at new path, chunked files with their chunks
+	if err = client.copySecret(args[0], args[1], secret.Data); err != nil {
 		cmd.ui.Error(err.Error())
 		return ServerError
 	}
@@ -90,6 +108,7 @@ func CopyCommandFactory(ui cli.Ui) cli.CommandFactory {
 
 		cmd.fs = flag.NewFlagSet("cp", flag.ContinueOnError)
 		cmd.fs.BoolVar(&cmd.force, "f", false, "force overwrite")
+		cmd.fs.BoolVar(&cmd.recurse, "R", false, "recursively copy secrets below the source path")
 		cmd.fs.Usage = func() {
 			fmt.Print(cmd.Help())
 		}
@@ -3,12 +3,40 @@ package vc
 import "testing"
 
 func TestCopyCommand(t *testing.T) {
+	_, client := testMemoryClient()
+	testWrite(t, client, "secret/src", map[string]interface{}{"key": "value"})
+	testWrite(t, client, "secret/exists", map[string]interface{}{"key": "other"})
+
 	for _, test := range []testCommand{
 		testCommand{
 			Factory: CopyCommandFactory,
 			Args:    []string{"--help"},
 			Code:    Success,
 		},
+		testCommand{
+			Factory: CopyCommandFactory,
+			Args:    []string{"secret/src", "kv/dst"},
+			Code:    Success,
+			Client:  client,
+		},
+		testCommand{
+			Factory: CopyCommandFactory,
+			Args:    []string{"secret/missing", "kv/dst"},
+			Code:    SyntaxError,
+			Client:  client,
+		},
+		testCommand{
+			Factory: CopyCommandFactory,
+			Args:    []string{"secret/src", "secret/exists"},
+			Code:    SystemError,
+			Client:  client,
+		},
+		testCommand{
+			Factory: CopyCommandFactory,
+			Args:    []string{"-f", "kv/dst", "secret/exists"},
+			Code:    Success,
+			Client:  client,
+		},
 	} {
 		if test.Live {
 			if err := testLiveAvailable(); err != nil {
@@ -17,4 +45,128 @@ func TestCopyCommand(t *testing.T) {
 		}
 		testCommandRun(t, test)
 	}
+
+	for _, path := range []string{"kv/dst", "secret/exists"} {
+		secret, err := client.Read(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if secret == nil || secret.Data["key"] != "value" {
+			t.Fatalf("expected %s to be a copy, got %+v", path, secret)
+		}
+	}
+}
+
+func TestCopyCommandRecursive(t *testing.T) {
+	_, client := testMemoryClient()
+	testWrite(t, client, "secret/app", map[string]interface{}{"key": "app"})
+	testWrite(t, client, "secret/app/db", map[string]interface{}{"key": "db"})
+	testWrite(t, client, "secret/app/api/token", map[string]interface{}{"key": "token"})
+	testWrite(t, client, "kv/copy/db", map[string]interface{}{"key": "exists"})
+
+	for _, test := range []testCommand{
+		testCommand{
+			Factory: CopyCommandFactory,
+			Args:    []string{"-R", "secret/missing", "kv/copy"},
+			Code:    SyntaxError,
+			Client:  client,
+		},
+		testCommand{
+			// Conflict on kv/copy/db, which isn't overwritten
+			Factory: CopyCommandFactory,
+			Args:    []string{"-R", "secret/app", "kv/copy"},
+			Code:    ServerError,
+			Client:  client,
+		},
+		testCommand{
+			Factory: MoveCommandFactory,
+			Args:    []string{"-R", "-f", "secret/app", "kv/move"},
+			Code:    Success,
+			Client:  client,
+		},
+	} {
+		testCommandRun(t, test)
+	}
+
+	for path, want := range map[string]interface{}{
+		"kv/copy":           "app",
+		"kv/copy/db":        "exists",
+		"kv/copy/api/token": "token",
+		"kv/move":           "app",
+		"kv/move/db":        "db",
+		"kv/move/api/token": "token",
+		"secret/app":        nil,
+		"secret/app/db":     nil,
+	} {
+		secret, err := client.Read(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want == nil {
+			if secret != nil {
+				t.Fatalf("expected %s to be moved, got %+v", path, secret)
+			}
+		} else if secret == nil || secret.Data["key"] != want {
+			t.Fatalf("expected %s to have key %q, got %+v", path, want, secret)
+		}
+	}
+}
+
+func TestCopyCommandGlob(t *testing.T) {
+	_, client := testMemoryClient()
+	testWrite(t, client, "secret/app/tmp-1", map[string]interface{}{"key": "1"})
+	testWrite(t, client, "secret/app/tmp-2", map[string]interface{}{"key": "2"})
+	testWrite(t, client, "secret/app/db", map[string]interface{}{"key": "db"})
+
+	for _, test := range []testCommand{
+		testCommand{
+			Factory: CopyCommandFactory,
+			Args:    []string{"secret/app/none-*", "kv/"},
+			Code:    SyntaxError,
+			Client:  client,
+		},
+		testCommand{
+			Factory: CopyCommandFactory,
+			Args:    []string{"secret/app/tmp-*", "secret/app/db", "kv/copy/"},
+			Code:    Success,
+			Client:  client,
+		},
+		testCommand{
+			// Glob expansion requires confirmation
+			Factory: MoveCommandFactory,
+			Args:    []string{"secret/app/tmp-*", "kv/move/"},
+			Code:    1,
+			Client:  client,
+		},
+		testCommand{
+			Factory: MoveCommandFactory,
+			Args:    []string{"-f", "secret/app/tmp-*", "kv/move/"},
+			Code:    Success,
+			Client:  client,
+		},
+	} {
+		testCommandRun(t, test)
+	}
+
+	for path, want := range map[string]interface{}{
+		"kv/copy/tmp-1":    "1",
+		"kv/copy/tmp-2":    "2",
+		"kv/copy/db":       "db",
+		"kv/move/tmp-1":    "1",
+		"kv/move/tmp-2":    "2",
+		"secret/app/tmp-1": nil,
+		"secret/app/db":    "db",
+	} {
+		secret, err := client.Read(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want == nil {
+			if secret != nil {
+				t.Fatalf("expected %s to be moved, got %+v", path, secret)
+			}
+		} else if secret == nil || secret.Data["key"] != want {
+			t.Fatalf("expected %s to have key %q, got %+v", path, want, secret)
+		}
+	}
 }
@@ -0,0 +1,258 @@
+package vc
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/nacl/box"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Sealed data starts with sealMagic, the format version and the seal mode:
+//
+//   passphrase: salt, scrypt parameters, nonce, secretbox
+//   keys:       number of recipients, anonymous box of the data key for each
+//               recipient, nonce, secretbox
+const (
+	sealMagic          = "vcsealed"
+	sealVersion        = 1
+	sealModePassphrase = 'p'
+	sealModeKeys       = 'k'
+	sealKeySize        = 32
+	sealNonceSize      = 24
+	sealSaltSize       = 16
+	sealHeaderSize     = len(sealMagic) + 2
+
+	scryptLogN = 15
+	scryptR    = 8
+	scryptP    = 1
+
+	// Upper bounds for the scrypt parameters read from sealed data, so crafted
+	// input can not make key derivation exhaust memory
+	scryptMaxLogN = 20
+	scryptMaxR    = 32
+	scryptMaxP    = 16
+)
+
+// PassphraseEnv is the environment variable with the passphrase for sealing
+// and opening, if not set the user is prompted
+const PassphraseEnv = "VC_PASSPHRASE"
+
+var (
+	// ErrNotSealed is returned when opening data that was not sealed by vc
+	ErrNotSealed = errors.New("vc: data is not encrypted")
+
+	// ErrOpen is returned if sealed data could not be decrypted
+	ErrOpen = errors.New("vc: decryption failed")
+)
+
+// isSealed checks if p is sealed data
+func isSealed(p []byte) bool {
+	return len(p) >= sealHeaderSize && string(p[:len(sealMagic)]) == sealMagic
+}
+
+// sealMode returns the seal mode of sealed data
+func sealMode(p []byte) (byte, error) {
+	if !isSealed(p) {
+		return 0, ErrNotSealed
+	}
+	if version := p[len(sealMagic)]; version != sealVersion {
+		return 0, fmt.Errorf("vc: unsupported encryption version %d", version)
+	}
+	return p[len(sealMagic)+1], nil
+}
+
+func sealHeader(mode byte) []byte {
+	return append([]byte(sealMagic), sealVersion, mode)
+}
+
+// sealBox encrypts p with key using secretbox, prefixed with the nonce
+func sealBox(out, p []byte, key *[sealKeySize]byte) ([]byte, error) {
+	var nonce [sealNonceSize]byte
+	if _, err := io.ReadFull(rand.Reader, nonce[:]); err != nil {
+		return nil, err
+	}
+	out = append(out, nonce[:]...)
+	return secretbox.Seal(out, p, &nonce, key), nil
+}
+
+// openBox decrypts a nonce prefixed secretbox
+func openBox(p []byte, key *[sealKeySize]byte) ([]byte, error) {
+	if len(p) < sealNonceSize+secretbox.Overhead {
+		return nil, ErrOpen
+	}
+	var nonce [sealNonceSize]byte
+	copy(nonce[:], p)
+	out, ok := secretbox.Open(nil, p[sealNonceSize:], &nonce, key)
+	if !ok {
+		return nil, ErrOpen
+	}
+	return out, nil
+}
+
+// passphraseKey derives a key from a passphrase with scrypt
+func passphraseKey(passphrase, salt []byte, logN, r, p int) (*[sealKeySize]byte, error) {
+	b, err := scrypt.Key(passphrase, salt, 1<<uint(logN), r, p, sealKeySize)
+	if err != nil {
+		return nil, err
+	}
+	var key [sealKeySize]byte
+	copy(key[:], b)
+	return &key, nil
+}
+
+// sealPassphrase encrypts p with a key derived from passphrase
+func sealPassphrase(p, passphrase []byte) ([]byte, error) {
+	salt := make([]byte, sealSaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	key, err := passphraseKey(passphrase, salt, scryptLogN, scryptR, scryptP)
+	if err != nil {
+		return nil, err
+	}
+
+	out := sealHeader(sealModePassphrase)
+	out = append(out, salt...)
+	out = append(out, scryptLogN, scryptR, scryptP)
+	return sealBox(out, p, key)
+}
+
+// openPassphrase decrypts data sealed with sealPassphrase
+func openPassphrase(p, passphrase []byte) ([]byte, error) {
+	if mode, err := sealMode(p); err != nil {
+		return nil, err
+	} else if mode != sealModePassphrase {
+		return nil, fmt.Errorf("vc: data is not encrypted with a passphrase")
+	}
+	p = p[sealHeaderSize:]
+	if len(p) < sealSaltSize+3 {
+		return nil, ErrOpen
+	}
+	salt, params := p[:sealSaltSize], p[sealSaltSize:sealSaltSize+3]
+	if params[0] == 0 || params[0] > scryptMaxLogN || params[1] == 0 || params[1] > scryptMaxR || params[2] == 0 || params[2] > scryptMaxP {
+		return nil, fmt.Errorf("vc: unsupported scrypt parameters N=2^%d, r=%d, p=%d", params[0], params[1], params[2])
+	}
+	key, err := passphraseKey(passphrase, salt, int(params[0]), int(params[1]), int(params[2]))
+	if err != nil {
+		return nil, err
+	}
+	return openBox(p[sealSaltSize+3:], key)
+}
+
+// sealKeys encrypts p with a random data key, which is sealed to each of the
+// recipient public keys
+func sealKeys(p []byte, recipients []*[sealKeySize]byte) ([]byte, error) {
+	if len(recipients) == 0 || len(recipients) > 0xffff {
+		return nil, fmt.Errorf("vc: invalid number of recipients %d", len(recipients))
+	}
+
+	var key [sealKeySize]byte
+	if _, err := io.ReadFull(rand.Reader, key[:]); err != nil {
+		return nil, err
+	}
+
+	out := sealHeader(sealModeKeys)
+	out = append(out, 0, 0)
+	binary.BigEndian.PutUint16(out[len(out)-2:], uint16(len(recipients)))
+	for _, recipient := range recipients {
+		var err error
+		if out, err = box.SealAnonymous(out, key[:], recipient, rand.Reader); err != nil {
+			return nil, err
+		}
+	}
+	return sealBox(out, p, &key)
+}
+
+// openKeys decrypts data sealed with sealKeys using a private key
+func openKeys(p []byte, privateKey *[sealKeySize]byte) ([]byte, error) {
+	if mode, err := sealMode(p); err != nil {
+		return nil, err
+	} else if mode != sealModeKeys {
+		return nil, fmt.Errorf("vc: data is not encrypted with public keys")
+	}
+	p = p[sealHeaderSize:]
+	if len(p) < 2 {
+		return nil, ErrOpen
+	}
+	n := int(binary.BigEndian.Uint16(p))
+	p = p[2:]
+
+	const size = box.AnonymousOverhead + sealKeySize
+	if len(p) < n*size {
+		return nil, ErrOpen
+	}
+
+	var publicKey [sealKeySize]byte
+	curve25519.ScalarBaseMult(&publicKey, privateKey)
+	for i := 0; i < n; i++ {
+		b, ok := box.OpenAnonymous(nil, p[i*size:(i+1)*size], &publicKey, privateKey)
+		if !ok {
+			continue
+		}
+		var key [sealKeySize]byte
+		copy(key[:], b)
+		return openBox(p[n*size:], &key)
+	}
+	return nil, fmt.Errorf("vc: data is not encrypted for this key")
+}
+
+// generateSealKey generates a public and private key pair for sealKeys
+func generateSealKey() (publicKey, privateKey *[sealKeySize]byte, err error) {
+	return box.GenerateKey(rand.Reader)
+}
+
+// encodeSealKey encodes a key for storage in a key file
+func encodeSealKey(key *[sealKeySize]byte) []byte {
+	return []byte(base64.StdEncoding.EncodeToString(key[:]) + "\n")
+}
+
+// readSealKey reads a public or private key from a key file
+func readSealKey(name string) (*[sealKeySize]byte, error) {
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	p, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(b)))
+	if err != nil || len(p) != sealKeySize {
+		return nil, fmt.Errorf("vc: %s: invalid key", name)
+	}
+	var key [sealKeySize]byte
+	copy(key[:], p)
+	return &key, nil
+}
+
+// sealWriter buffers all output and writes it sealed to w on Close
+type sealWriter struct {
+	w    io.WriteCloser
+	buf  bytes.Buffer
+	seal func([]byte) ([]byte, error)
+}
+
+func (w *sealWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *sealWriter) Close() error {
+	sealed, err := w.seal(w.buf.Bytes())
+	if err != nil {
+		return err
+	}
+	if _, err = w.w.Write(sealed); err != nil {
+		return err
+	}
+	if w.w == os.Stdout {
+		return nil
+	}
+	return w.w.Close()
+}
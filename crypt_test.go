@@ -0,0 +1,152 @@
+package vc
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type nopWriteCloser struct {
+	bytes.Buffer
+}
+
+func (w *nopWriteCloser) Close() error { return nil }
+
+func TestSealPassphrase(t *testing.T) {
+	plain := []byte("test secret")
+	sealed, err := sealPassphrase(plain, []byte("correct horse"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isSealed(sealed) || bytes.Contains(sealed, plain) {
+		t.Fatalf("expected sealed data, got %q", sealed)
+	}
+
+	opened, err := openPassphrase(sealed, []byte("correct horse"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(opened, plain) {
+		t.Fatalf("expected %q, got %q", plain, opened)
+	}
+
+	if _, err = openPassphrase(sealed, []byte("battery staple")); err != ErrOpen {
+		t.Fatalf("expected %v for wrong passphrase, got %v", ErrOpen, err)
+	}
+	if _, err = openPassphrase(plain, []byte("correct horse")); err != ErrNotSealed {
+		t.Fatalf("expected %v for plain data, got %v", ErrNotSealed, err)
+	}
+
+	// Crafted scrypt parameters are rejected before deriving the key
+	for _, params := range [][3]byte{{40, 8, 1}, {15, 255, 1}, {15, 8, 64}, {0, 8, 1}} {
+		crafted := append([]byte(nil), sealed...)
+		copy(crafted[sealHeaderSize+sealSaltSize:], params[:])
+		if _, err = openPassphrase(crafted, []byte("correct horse")); err == nil || !strings.Contains(err.Error(), "unsupported scrypt parameters") {
+			t.Fatalf("expected unsupported parameters error for %v, got %v", params, err)
+		}
+	}
+}
+
+func TestSealKeys(t *testing.T) {
+	var (
+		plain   = []byte("test secret")
+		publics = make([]*[sealKeySize]byte, 3)
+		private = make([]*[sealKeySize]byte, 3)
+	)
+	for i := range publics {
+		var err error
+		if publics[i], private[i], err = generateSealKey(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sealed, err := sealKeys(plain, publics[:2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, key := range private[:2] {
+		opened, err := openKeys(sealed, key)
+		if err != nil {
+			t.Fatalf("recipient %d: %v", i, err)
+		}
+		if !bytes.Equal(opened, plain) {
+			t.Fatalf("recipient %d: expected %q, got %q", i, plain, opened)
+		}
+	}
+	if _, err = openKeys(sealed, private[2]); err == nil {
+		t.Fatal("expected error for key that is not a recipient")
+	}
+	if _, err = openPassphrase(sealed, []byte("test")); err == nil {
+		t.Fatal("expected error opening with passphrase")
+	}
+}
+
+func TestSealWriter(t *testing.T) {
+	out := new(nopWriteCloser)
+	w := &sealWriter{w: out, seal: func(p []byte) ([]byte, error) {
+		return sealPassphrase(p, []byte("test"))
+	}}
+	w.Write([]byte("hello, "))
+	w.Write([]byte("world"))
+	if out.Len() != 0 {
+		t.Fatal("expected no output before close")
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	opened, err := openPassphrase(out.Bytes(), []byte("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(opened) != "hello, world" {
+		t.Fatalf("expected %q, got %q", "hello, world", opened)
+	}
+}
+
+func TestExportImportEncrypted(t *testing.T) {
+	ReplaceCodec(archiveJSON, new(testCodec))
+	os.Setenv(PassphraseEnv, "test")
+	defer os.Unsetenv(PassphraseEnv)
+
+	dir, err := ioutil.TempDir("", "vc-test")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer os.RemoveAll(dir)
+
+	_, client := testMemoryClient()
+	testWrite(t, client, "secret/team/db", map[string]interface{}{"password": "test"})
+
+	archive := filepath.Join(dir, "team.json")
+	for _, test := range []testCommand{
+		testCommand{
+			Factory: ExportCommandFactory,
+			Args:    []string{"-encrypt", "-o", archive, "secret/team"},
+			Code:    Success,
+			Client:  client,
+		},
+		testCommand{
+			Factory: ImportCommandFactory,
+			Args:    []string{archive, "kv/team"},
+			Code:    Success,
+			Client:  client,
+		},
+	} {
+		testCommandRun(t, test)
+	}
+
+	b, err := ioutil.ReadFile(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isSealed(b) {
+		t.Fatal("expected encrypted archive")
+	}
+	if secret, err := client.Read("kv/team/db"); err != nil || secret == nil || secret.Data["password"] != "test" {
+		t.Fatalf("expected imported secret, got %+v (%v)", secret, err)
+	}
+}
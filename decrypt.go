@@ -0,0 +1,122 @@
+package vc
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+
+	"github.com/mitchellh/cli"
+)
+
+// DecryptCommand decrypts output encrypted by vc
+type DecryptCommand struct {
+	baseCommand
+	fs       *flag.FlagSet
+	mod      string
+	key      string
+	generate bool
+}
+
+func (cmd *DecryptCommand) Help() string {
+	return "Usage: vc decrypt [<options>] [<file>]\n" +
+		"       vc decrypt -generate <private key file>\n\nOptions:\n" + defaults(cmd.fs)
+}
+
+func (cmd *DecryptCommand) Run(args []string) int {
+	if err := cmd.fs.Parse(args); err != nil {
+		return SyntaxError
+	}
+	if args = cmd.fs.Args(); len(args) > 1 || (cmd.generate && len(args) != 1) {
+		return Help
+	}
+
+	if cmd.generate {
+		return cmd.runGenerate(args[0])
+	}
+
+	mode, err := strconv.ParseInt(cmd.mod, 8, 32)
+	if err != nil {
+		cmd.ui.Error("error: invalid mode: " + err.Error())
+		return SyntaxError
+	}
+
+	var p []byte
+	if len(args) == 0 || args[0] == "-" {
+		p, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		p, err = ioutil.ReadFile(args[0])
+	}
+	if err != nil {
+		cmd.ui.Error(fmt.Sprintf("error: %v", err))
+		return SystemError
+	}
+
+	if p, err = cmd.unseal(p, cmd.key); err != nil {
+		cmd.ui.Error(fmt.Sprintf("error: %v", err))
+		return CodecError
+	}
+
+	w := SafeOutputWriter(cmd.out, os.FileMode(mode))
+	if _, err = w.Write(p); err == nil && w != os.Stdout {
+		err = w.Close()
+	}
+	if err != nil {
+		cmd.ui.Error(fmt.Sprintf("error: %v", err))
+		return SystemError
+	}
+
+	return Success
+}
+
+// runGenerate generates a key pair; the public key is written next to the
+// private key with a .pub suffix
+func (cmd *DecryptCommand) runGenerate(name string) int {
+	if _, err := os.Stat(name); err == nil {
+		cmd.ui.Error(fmt.Sprintf("error: %s: already exists", name))
+		return SyntaxError
+	}
+
+	publicKey, privateKey, err := generateSealKey()
+	if err != nil {
+		cmd.ui.Error(fmt.Sprintf("error: %v", err))
+		return SystemError
+	}
+	if err = ioutil.WriteFile(name, encodeSealKey(privateKey), 0600); err != nil {
+		cmd.ui.Error(fmt.Sprintf("error: %v", err))
+		return SystemError
+	}
+	if err = ioutil.WriteFile(name+".pub", encodeSealKey(publicKey), 0644); err != nil {
+		cmd.ui.Error(fmt.Sprintf("error: %v", err))
+		return SystemError
+	}
+
+	cmd.ui.Info(fmt.Sprintf("private key written to %s, public key to %s.pub", name, name))
+	return Success
+}
+
+func (cmd *DecryptCommand) Synopsis() string {
+	return "decrypt output encrypted by vc"
+}
+
+func DecryptCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		cmd := &DecryptCommand{
+			baseCommand: baseCommand{
+				ui: ui,
+			},
+		}
+
+		cmd.fs = flag.NewFlagSet("decrypt", flag.ContinueOnError)
+		cmd.fs.BoolVar(&cmd.generate, "generate", false, "generate a key pair for encryption to public keys")
+		cmd.fs.StringVar(&cmd.key, "k", "", "private key file (for output encrypted to public keys)")
+		cmd.fs.StringVar(&cmd.mod, "m", "0600", "output mode")
+		cmd.fs.StringVar(&cmd.out, "o", "", "output (default stdout)")
+		cmd.fs.Usage = func() {
+			fmt.Print(cmd.Help())
+		}
+
+		return cmd, nil
+	}
+}
@@ -3,49 +3,170 @@ package vc
 import (
 	"flag"
 	"fmt"
-	"strings"
+	"os"
 
 	"github.com/mitchellh/cli"
 )
 
-// DeleteCommand can display (structured) secrets
+// DeleteCommand removes secrets; for secrets on KV version 2 mounts it can
+// also undelete or destroy versions
 type DeleteCommand struct {
 	baseCommand
-	fs    *flag.FlagSet
-	force bool
+	fs       *flag.FlagSet
+	sub      string
+	force    bool
+	recurse  bool
+	versions string
 }
 
 func (cmd *DeleteCommand) Help() string {
-	return "Usage: vc rm <secret path>\n\nOptions:\n" + defaults(cmd.fs)
+	if cmd.sub == "rm" {
+		return "Usage: vc rm [<options>] <secret path> [... <secret path>]\n\nOptions:\n" + defaults(cmd.fs)
+	}
+	return "Usage: vc " + cmd.sub + " [<options>] <secret path>\n\nOptions:\n" + defaults(cmd.fs)
 }
 
 func (cmd *DeleteCommand) Run(args []string) int {
 	if err := cmd.fs.Parse(args); err != nil {
 		return SyntaxError
 	}
-	if args = cmd.fs.Args(); len(args) != 1 {
+	if args = cmd.fs.Args(); len(args) < 1 || (cmd.sub != "rm" && len(args) != 1) {
 		return Help
 	}
 
+	versions, err := parseVersions(cmd.versions)
+	if err != nil {
+		cmd.ui.Error("error: " + err.Error())
+		return SyntaxError
+	}
+	if cmd.recurse && len(versions) > 0 {
+		cmd.ui.Error("error: -V can not be combined with -R")
+		return SyntaxError
+	}
+
 	client, err := cmd.Client()
 	if err != nil {
 		cmd.ui.Error(err.Error())
 		return ClientError
 	}
 
-	if !cmd.force {
-		secret, err := client.Logical().Read(strings.TrimLeft(args[0], "/"))
-		if err != nil {
+	switch cmd.sub {
+	case "undelete", "destroy":
+		return cmd.runVersions(client, args[0], versions)
+	}
+
+	var (
+		paths     = args
+		confirmed = cmd.force
+	)
+	if cmd.hasGlobs(client, args) {
+		if paths, err = cmd.globs(client, args); err != nil {
+			cmd.ui.Error(fmt.Sprintf("error: %v", err))
+			return SyntaxError
+		}
+		if ok, err := cmd.confirmExpansion("remove", paths, cmd.force); err != nil {
+			cmd.ui.Error(err.Error())
+			return SystemError
+		} else if !ok {
+			return Success
+		}
+		// The trees below the expanded paths are not confirmed one by one
+		confirmed = true
+	}
+
+	var ret int
+	for _, path := range paths {
+		if code := cmd.remove(client, path, versions, confirmed); code > ret {
+			ret = code
+		}
+	}
+	return ret
+}
+
+// remove a single secret, or the secrets below path in recursive mode; trees
+// are removed without asking if confirmed
+func (cmd *DeleteCommand) remove(client *Client, path string, versions []int, confirmed bool) int {
+	if cmd.recurse {
+		return cmd.deleteTree(client, path, confirmed)
+	}
+
+	if len(versions) > 0 {
+		if err := client.DeleteVersions(path, versions); err != nil {
+			cmd.ui.Error(err.Error())
+			return ServerError
+		}
+		return Success
+	}
+
+	// The secret is only read to find the chunks of chunked files; forced
+	// removal does not require read permission
+	var data map[string]interface{}
+	secret, err := client.Read(path)
+	if err != nil {
+		if !cmd.force {
 			cmd.ui.Error(err.Error())
 			return ServerError
 		}
-		if secret == nil {
-			cmd.ui.Error(fmt.Sprintf("secret at %q does not exist", args[0]))
+		Debugf("delete: %s: %v", path, err)
+	} else if secret != nil {
+		data = secret.Data
+	} else if !cmd.force {
+		cmd.ui.Error(fmt.Sprintf("secret at %q does not exist", path))
+		return SyntaxError
+	}
+
+	if err = client.deleteFile(path, data); err != nil {
+		cmd.ui.Error(err.Error())
+		return ServerError
+	}
+
+	return Success
+}
+
+// runVersions undeletes or destroys versions, defaults to the current version
+func (cmd *DeleteCommand) runVersions(client *Client, path string, versions []int) int {
+	meta, err := client.Metadata(path)
+	if err == ErrNotVersioned {
+		cmd.ui.Error(fmt.Sprintf("error: %s: %v", path, err))
+		return SyntaxError
+	} else if err != nil {
+		cmd.ui.Error(err.Error())
+		return ServerError
+	}
+	if meta == nil {
+		cmd.ui.Error(fmt.Sprintf("secret at %q does not exist", path))
+		return SyntaxError
+	}
+
+	if len(versions) == 0 {
+		versions = []int{meta.CurrentVersion}
+	}
+	for _, version := range versions {
+		if _, ok := meta.Version(version); !ok {
+			cmd.ui.Error(fmt.Sprintf("secret at %q has no version %d", path, version))
 			return SyntaxError
 		}
 	}
 
-	if _, err := client.Logical().Delete(strings.TrimLeft(args[0], "/")); err != nil {
+	if cmd.sub == "undelete" {
+		if err = client.Undelete(path, versions); err != nil {
+			cmd.ui.Error(err.Error())
+			return ServerError
+		}
+		return Success
+	}
+
+	if !cmd.force {
+		if !IsTerminal(os.Stdout.Fd()) {
+			cmd.ui.Error(fmt.Sprintf("refusing to destroy versions of %q without force", path))
+			return SystemError
+		}
+		if !confirmf("permanently destroy version(s) %v of %s?", versions, path) {
+			return Success
+		}
+	}
+
+	if err = client.Destroy(path, versions); err != nil {
 		cmd.ui.Error(err.Error())
 		return ServerError
 	}
@@ -54,19 +175,49 @@ func (cmd *DeleteCommand) Run(args []string) int {
 }
 
 func (cmd *DeleteCommand) Synopsis() string {
-	return "remove a secret"
+	switch cmd.sub {
+	case "undelete":
+		return "restore deleted versions of a secret"
+	case "destroy":
+		return "permanently remove versions of a secret"
+	default:
+		return "remove a secret"
+	}
 }
 
 func DeleteCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return deleteCommandFactory(ui, "rm")
+}
+
+func UndeleteCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return deleteCommandFactory(ui, "undelete")
+}
+
+func DestroyCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return deleteCommandFactory(ui, "destroy")
+}
+
+func deleteCommandFactory(ui cli.Ui, sub string) cli.CommandFactory {
 	return func() (cli.Command, error) {
 		cmd := &DeleteCommand{
+			sub: sub,
 			baseCommand: baseCommand{
 				ui: ui,
 			},
 		}
 
-		cmd.fs = flag.NewFlagSet("rm", flag.ContinueOnError)
-		cmd.fs.BoolVar(&cmd.force, "f", false, "force removal")
+		cmd.fs = flag.NewFlagSet(sub, flag.ContinueOnError)
+		switch sub {
+		case "rm":
+			cmd.fs.BoolVar(&cmd.force, "f", false, "force removal")
+			cmd.fs.BoolVar(&cmd.recurse, "R", false, "recursively remove secrets below the path")
+			cmd.fs.StringVar(&cmd.versions, "V", "", "comma separated versions to delete (KV version 2)")
+		case "undelete":
+			cmd.fs.StringVar(&cmd.versions, "V", "", "comma separated versions to undelete (default: current version)")
+		case "destroy":
+			cmd.fs.BoolVar(&cmd.force, "f", false, "force destroy without confirmation")
+			cmd.fs.StringVar(&cmd.versions, "V", "", "comma separated versions to destroy (default: current version)")
+		}
 		cmd.fs.Usage = func() {
 			fmt.Print(cmd.Help())
 		}
@@ -1,14 +1,128 @@
 package vc
 
-import "testing"
+import (
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+)
 
 func TestDeleteCommand(t *testing.T) {
+	_, client := testMemoryClient()
+	testWrite(t, client, "secret/test", map[string]interface{}{"key": "value"})
+	testWrite(t, client, "kv/test", map[string]interface{}{"key": "value"})
+	testWrite(t, client, "kv/test", map[string]interface{}{"key": "changed"})
+	testWrite(t, client, "secret/tree/a", map[string]interface{}{"key": "value"})
+	testWrite(t, client, "secret/tree/b/c", map[string]interface{}{"key": "value"})
+	testWrite(t, client, "secret/tmp-1", map[string]interface{}{"key": "value"})
+	testWrite(t, client, "secret/tmp-2", map[string]interface{}{"key": "value"})
+	testWrite(t, client, "secret/trees-1/a", map[string]interface{}{"key": "value"})
+	testWrite(t, client, "secret/trees-2/b/c", map[string]interface{}{"key": "value"})
+
 	for _, test := range []testCommand{
 		testCommand{
 			Factory: DeleteCommandFactory,
 			Args:    []string{"--help"},
 			Code:    Success,
 		},
+		testCommand{
+			Factory: UndeleteCommandFactory,
+			Args:    []string{"--help"},
+			Code:    Success,
+		},
+		testCommand{
+			Factory: DestroyCommandFactory,
+			Args:    []string{"--help"},
+			Code:    Success,
+		},
+		testCommand{
+			Factory: DestroyCommandFactory,
+			Args:    []string{"-V", "x", "secret/test"},
+			Code:    SyntaxError,
+		},
+		testCommand{
+			Factory: DeleteCommandFactory,
+			Args:    []string{"-R", "-V", "1", "kv/test"},
+			Code:    SyntaxError,
+		},
+		testCommand{
+			Factory: DeleteCommandFactory,
+			Args:    []string{"secret/test"},
+			Code:    Success,
+			Client:  client,
+		},
+		testCommand{
+			Factory: DeleteCommandFactory,
+			Args:    []string{"secret/test"},
+			Code:    SyntaxError,
+			Client:  client,
+		},
+		testCommand{
+			Factory: DeleteCommandFactory,
+			Args:    []string{"kv/test"},
+			Code:    Success,
+			Client:  client,
+		},
+		testCommand{
+			Factory: DeleteCommandFactory,
+			Args:    []string{"-R", "secret/tree"},
+			Code:    SystemError,
+			Client:  client,
+		},
+		testCommand{
+			Factory: DeleteCommandFactory,
+			Args:    []string{"-R", "-f", "secret/tree"},
+			Code:    Success,
+			Client:  client,
+		},
+		testCommand{
+			Factory: DeleteCommandFactory,
+			Args:    []string{"secret/tmp-*"},
+			Code:    SystemError,
+			Client:  client,
+		},
+		testCommand{
+			Factory: DeleteCommandFactory,
+			Args:    []string{"-f", "secret/tmp-*"},
+			Code:    Success,
+			Client:  client,
+		},
+		testCommand{
+			Factory: DeleteCommandFactory,
+			Args:    []string{"-R", "secret/trees-*"},
+			Code:    SystemError,
+			Client:  client,
+		},
+		testCommand{
+			Factory: DeleteCommandFactory,
+			Args:    []string{"-R", "-f", "secret/trees-*"},
+			Code:    Success,
+			Client:  client,
+		},
+		testCommand{
+			Factory: UndeleteCommandFactory,
+			Args:    []string{"secret/test"},
+			Code:    SyntaxError,
+			Client:  client,
+		},
+		testCommand{
+			Factory: UndeleteCommandFactory,
+			Args:    []string{"kv/test"},
+			Code:    Success,
+			Client:  client,
+		},
+		testCommand{
+			Factory: DestroyCommandFactory,
+			Args:    []string{"-V", "3", "kv/test"},
+			Code:    SyntaxError,
+			Client:  client,
+		},
+		testCommand{
+			Factory: DestroyCommandFactory,
+			Args:    []string{"-f", "-V", "1", "kv/test"},
+			Code:    Success,
+			Client:  client,
+		},
 	} {
 		if test.Live {
 			if err := testLiveAvailable(); err != nil {
@@ -17,4 +131,77 @@ func TestDeleteCommand(t *testing.T) {
 		}
 		testCommandRun(t, test)
 	}
+
+	if infos, err := client.ReadDir("secret/tree"); err != nil || len(infos) > 0 {
+		t.Fatalf("expected secret/tree to be removed, got %v (%v)", infos, err)
+	}
+
+	if infos, err := client.Glob("secret/tmp-*"); err != nil || len(infos) > 0 {
+		t.Fatalf("expected secret/tmp-* to be removed, got %v (%v)", infos, err)
+	}
+	for _, root := range []string{"secret/trees-1", "secret/trees-2"} {
+		if paths := testSecretPaths(t, client, root); len(paths) > 0 {
+			t.Fatalf("expected %s to be removed, got %q", root, paths)
+		}
+	}
+
+	secret, err := client.Read("kv/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret == nil || secret.Data["key"] != "changed" {
+		t.Fatalf("expected kv/test to be undeleted, got %+v", secret)
+	}
+	meta, err := client.Metadata("kv/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := meta.Version(1); !v.Destroyed {
+		t.Fatalf("expected version 1 of kv/test to be destroyed, got %+v", v)
+	}
+}
+
+// deniedReadBackend denies reading secrets, as a token with only the delete
+// capability would
+type deniedReadBackend struct {
+	*MemoryBackend
+}
+
+func (b *deniedReadBackend) Read(path string) (*api.Secret, error) {
+	return nil, errors.New("permission denied")
+}
+
+func (b *deniedReadBackend) ReadWithData(path string, data map[string][]string) (*api.Secret, error) {
+	return nil, errors.New("permission denied")
+}
+
+func TestDeleteCommandDeniedRead(t *testing.T) {
+	backend := testBackend()
+	client := NewBackendClient(&deniedReadBackend{MemoryBackend: backend})
+	if _, err := backend.Write("secret/test", map[string]interface{}{"key": "value"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []testCommand{
+		testCommand{
+			Factory: DeleteCommandFactory,
+			Args:    []string{"secret/test"},
+			Code:    ServerError,
+			Client:  client,
+		},
+		testCommand{
+			Factory: DeleteCommandFactory,
+			Args:    []string{"-f", "secret/test"},
+			Code:    Success,
+			Client:  client,
+		},
+	} {
+		testCommandRun(t, test)
+	}
+
+	if secret, err := backend.Read("secret/test"); err != nil {
+		t.Fatal(err)
+	} else if secret != nil {
+		t.Fatalf("expected secret/test to be removed, got %+v", secret)
+	}
 }
@@ -0,0 +1,260 @@
+package vc
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+// Differences is the return code of diff if the secrets differ; like diff(1),
+// errors return a code above 1
+const Differences = 1
+
+// diffFilePrefix marks a diff operand as local file
+const diffFilePrefix = "file:"
+
+// DiffCommand compares secrets, trees of secrets and local files
+type DiffCommand struct {
+	baseCommand
+	fs      *flag.FlagSet
+	recurse bool
+	show    bool
+}
+
+func (cmd *DiffCommand) Help() string {
+	return "Usage: vc diff [<options>] <secret path|file:name> <secret path|file:name>\n\n" +
+		"The exit status is 0 if the secrets are the same, 1 if they differ and\n" +
+		"above 1 on errors.\n\nOptions:\n" + defaults(cmd.fs)
+}
+
+func (cmd *DiffCommand) Run(args []string) int {
+	// Help and syntax errors would return 1, which means the secrets differ
+	if err := cmd.fs.Parse(args); err != nil {
+		return UsageError
+	}
+	if args = cmd.fs.Args(); len(args) != 2 {
+		cmd.ui.Error(cmd.Help())
+		return UsageError
+	}
+
+	var (
+		a, b map[string]map[string]interface{}
+		err  error
+	)
+	if a, err = cmd.load(args[0]); err != nil {
+		cmd.ui.Error(fmt.Sprintf("error: %s: %v", args[0], err))
+		return ServerError
+	}
+	if b, err = cmd.load(args[1]); err != nil {
+		cmd.ui.Error(fmt.Sprintf("error: %s: %v", args[1], err))
+		return ServerError
+	}
+	if len(a) == 0 && len(b) == 0 {
+		cmd.ui.Error(fmt.Sprintf("error: no secrets at %q and %q", args[0], args[1]))
+		return ServerError
+	}
+
+	var differ bool
+	for _, name := range diffPaths(a, b) {
+		left, right := name, name
+		if !cmd.recurse {
+			left, right = args[0], args[1]
+		} else if name != "" {
+			left, right = path.Join(args[0], name), path.Join(args[1], name)
+		}
+
+		lines := diffData(a[name], b[name], cmd.show)
+		if len(lines) == 0 {
+			continue
+		}
+		differ = true
+
+		switch {
+		case a[name] == nil:
+			cmd.ui.Output("only in " + right)
+		case b[name] == nil:
+			cmd.ui.Output("only in " + left)
+		default:
+			cmd.ui.Output("--- " + left)
+			cmd.ui.Output("+++ " + right)
+		}
+		for _, line := range lines {
+			cmd.ui.Output(line)
+		}
+	}
+
+	if differ {
+		return Differences
+	}
+	return Success
+}
+
+// load the secrets for an operand, keyed by their relative path in recursive
+// mode and by "" otherwise
+func (cmd *DiffCommand) load(name string) (map[string]map[string]interface{}, error) {
+	if strings.HasPrefix(name, diffFilePrefix) {
+		return cmd.loadFile(strings.TrimPrefix(name, diffFilePrefix))
+	}
+
+	client, err := cmd.Client()
+	if err != nil {
+		return nil, err
+	}
+
+	paths := []string{""}
+	if cmd.recurse {
+		if paths, err = client.secretPaths(name); err != nil {
+			return nil, err
+		}
+	}
+
+	secrets := make(map[string]map[string]interface{})
+	for _, rel := range paths {
+		secret, err := client.Read(path.Join(name, rel))
+		if err != nil {
+			return nil, err
+		} else if secret != nil {
+			secrets[rel] = secret.Data
+		}
+	}
+	return secrets, nil
+}
+
+// loadFile loads a local file; in recursive mode it is an export archive,
+// otherwise it is decoded by the codec matching its extension
+func (cmd *DiffCommand) loadFile(name string) (map[string]map[string]interface{}, error) {
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	if isSealed(b) {
+		if b, err = cmd.unseal(b, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	if cmd.recurse {
+		a, err := readArchive(bytes.NewReader(b), "")
+		if err != nil {
+			return nil, err
+		}
+		return a.Secrets, nil
+	}
+
+	kind := strings.TrimPrefix(strings.ToLower(filepath.Ext(name)), ".")
+	if kind == "yml" {
+		kind = "yaml"
+	}
+	c, err := CodecFor(kind)
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.Unmarshal(b)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]map[string]interface{}{
+		"": normalizeData(data).(map[string]interface{}),
+	}, nil
+}
+
+// diffPaths returns the sorted union of the paths in a and b
+func diffPaths(a, b map[string]map[string]interface{}) []string {
+	seen := make(map[string]bool)
+	for name := range a {
+		seen[name] = true
+	}
+	for name := range b {
+		seen[name] = true
+	}
+	paths := make([]string, 0, len(seen))
+	for name := range seen {
+		paths = append(paths, name)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// diffData compares secret data key by key; removed keys are prefixed with
+// "-", added keys with "+" and changed keys with "~". Values are only included
+// if show is enabled.
+func diffData(a, b map[string]interface{}, show bool) (lines []string) {
+	keys := make(map[string]bool)
+	for key := range a {
+		keys[key] = true
+	}
+	for key := range b {
+		keys[key] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	for _, key := range sorted {
+		left, inA := a[key]
+		right, inB := b[key]
+		switch {
+		case !inB:
+			lines = append(lines, diffLine("-", key, show, left))
+		case !inA:
+			lines = append(lines, diffLine("+", key, show, right))
+		case diffValue(left) != diffValue(right):
+			lines = append(lines, diffLine("~", key, show, left, right))
+		}
+	}
+	return
+}
+
+func diffLine(mark, key string, show bool, values ...interface{}) string {
+	if !show {
+		return mark + " " + key
+	}
+	formatted := make([]string, len(values))
+	for i, value := range values {
+		formatted[i] = diffValue(value)
+	}
+	return mark + " " + key + ": " + strings.Join(formatted, " -> ")
+}
+
+// diffValue formats a value as JSON, so values decoded from different sources
+// compare equal
+func diffValue(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+func (cmd *DiffCommand) Synopsis() string {
+	return "compare secrets"
+}
+
+func DiffCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		cmd := &DiffCommand{
+			baseCommand: baseCommand{
+				ui: ui,
+			},
+		}
+
+		cmd.fs = flag.NewFlagSet("diff", flag.ContinueOnError)
+		cmd.fs.BoolVar(&cmd.recurse, "R", false, "recursively compare the secrets below the paths")
+		cmd.fs.BoolVar(&cmd.show, "show", false, "show the differing values")
+		cmd.fs.Usage = func() {
+			fmt.Print(cmd.Help())
+		}
+
+		return cmd, nil
+	}
+}
@@ -0,0 +1,111 @@
+package vc
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDiffData(t *testing.T) {
+	a := map[string]interface{}{
+		"same":    "value",
+		"number":  json.Number("42"),
+		"changed": "old",
+		"removed": "value",
+	}
+	b := map[string]interface{}{
+		"same":    "value",
+		"number":  float64(42),
+		"changed": "new",
+		"added":   "value",
+	}
+
+	if got, want := diffData(a, b, false), []string{"+ added", "~ changed", "- removed"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	if got, want := diffData(a, b, true), []string{`+ added: "value"`, `~ changed: "old" -> "new"`, `- removed: "value"`}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	if got := diffData(a, a, false); len(got) != 0 {
+		t.Fatalf("expected no differences, got %q", got)
+	}
+}
+
+func TestDiffCommand(t *testing.T) {
+	ReplaceCodec(archiveJSON, new(testCodec))
+
+	dir, err := ioutil.TempDir("", "vc-test")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer os.RemoveAll(dir)
+
+	local := filepath.Join(dir, "db.json")
+	if err = ioutil.WriteFile(local, []byte(`{"password": "staging"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, client := testMemoryClient()
+	testWrite(t, client, "secret/staging/db", map[string]interface{}{"password": "staging"})
+	testWrite(t, client, "secret/prod/db", map[string]interface{}{"password": "prod"})
+	testWrite(t, client, "kv/staging/db", map[string]interface{}{"password": "staging"})
+
+	for _, test := range []testCommand{
+		testCommand{
+			Factory: DiffCommandFactory,
+			Args:    []string{"--help"},
+			Code:    Success,
+		},
+		testCommand{
+			Factory: DiffCommandFactory,
+			Args:    []string{"-invalid", "secret/staging/db", "secret/prod/db"},
+			Code:    UsageError,
+		},
+		testCommand{
+			Factory: DiffCommandFactory,
+			Args:    []string{"secret/staging/db"},
+			Code:    UsageError,
+		},
+		testCommand{
+			Factory: DiffCommandFactory,
+			Args:    []string{"secret/staging/db", "kv/staging/db"},
+			Code:    Success,
+			Client:  client,
+		},
+		testCommand{
+			Factory: DiffCommandFactory,
+			Args:    []string{"secret/staging/db", "secret/prod/db"},
+			Code:    Differences,
+			Client:  client,
+		},
+		testCommand{
+			Factory: DiffCommandFactory,
+			Args:    []string{"-R", "secret/staging", "kv/staging"},
+			Code:    Success,
+			Client:  client,
+		},
+		testCommand{
+			Factory: DiffCommandFactory,
+			Args:    []string{"-R", "secret/staging", "secret/prod"},
+			Code:    Differences,
+			Client:  client,
+		},
+		testCommand{
+			Factory: DiffCommandFactory,
+			Args:    []string{"secret/staging/db", "file:" + local},
+			Code:    Success,
+			Client:  client,
+		},
+		testCommand{
+			Factory: DiffCommandFactory,
+			Args:    []string{"secret/missing", "kv/missing"},
+			Code:    ServerError,
+			Client:  client,
+		},
+	} {
+		testCommandRun(t, test)
+	}
+}
@@ -9,7 +9,6 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strconv"
-	"strings"
 	"time"
 
 	yaml "gopkg.in/yaml.v2"
@@ -54,46 +53,97 @@ func (cmd *EditCommand) Run(args []string) int {
 		return 2
 	}
 
+	// Expand globs (if any), matching secrets are edited one by one
+	paths, err := cmd.globs(client, args)
+	if err != nil {
+		cmd.ui.Error(fmt.Sprintf("error: %v", err))
+		return 1
+	}
+	for _, path := range paths {
+		if code := cmd.edit(client, path); code != 0 {
+			return code
+		}
+	}
+	return 0
+}
+
+// edit a single secret
+func (cmd *EditCommand) edit(client *Client, path string) int {
+	secret, err := client.Read(path)
+	if err != nil {
+		cmd.ui.Error(err.Error())
+		return 1
+	}
+
 	var (
 		name   string
-		exists bool
+		kind   string
+		exists = secret != nil
 	)
-	if name, exists, err = cmd.readSecret(client, args[0]); err != nil {
+	if name, kind, err = cmd.readSecret(client, path, secret); err != nil {
 		cmd.ui.Error(err.Error())
 		return 1
 	}
 	defer os.Remove(name)
 
-	var data map[string]interface{}
-	if data, err = cmd.editSecret(name); err != nil {
+	var (
+		data map[string]interface{}
+		b    []byte
+	)
+	if data, b, err = cmd.editSecret(name, kind); err != nil {
 		cmd.ui.Error(err.Error())
 		return 1
 	}
 
+	if kind == "file" {
+		return cmd.saveFile(client, path, secret.Data, b)
+	}
+
 	if len(data) == 0 {
 		if !exists {
 			cmd.ui.Warn("no data was saved")
 			return 0
 		}
-		if _, err = client.Logical().Delete(strings.TrimLeft(args[0], "/")); err != nil {
+		if _, err = client.Delete(path); err != nil {
 			cmd.ui.Error(err.Error())
 			return 1
 		}
-		cmd.ui.Info(fmt.Sprintf("secret at %s removed", args[0]))
+		cmd.ui.Info(fmt.Sprintf("secret at %s removed", path))
+		return 0
+	}
+
+	if _, err = client.Write(path, data); err != nil {
+		cmd.ui.Error(err.Error())
+		return 1
+	}
+
+	cmd.ui.Info(fmt.Sprintf("secret at %s saved", path))
+	return 0
+}
+
+// saveFile stores the edited contents b of the file secret at path with the
+// metadata and encoding of its old data; unchanged files are not written
+func (cmd *EditCommand) saveFile(client *Client, path string, old map[string]interface{}, b []byte) int {
+	if sum, _ := old[fileSHA256Key].(string); sum == sha256Hex(b) {
+		cmd.ui.Info(fmt.Sprintf("secret at %s unchanged", path))
 		return 0
 	}
 
-	if _, err = client.Logical().Write(strings.TrimLeft(args[0], "/"), data); err != nil {
+	info := newFileInfo(old)
+	info.modTime = time.Now()
+	encoding, _ := old[fileEncodingKey].(string)
+	if err := client.writeFile(path, b, info, encoding, 0); err != nil {
 		cmd.ui.Error(err.Error())
 		return 1
 	}
 
-	cmd.ui.Info(fmt.Sprintf("secret at %s saved", args[0]))
+	cmd.ui.Info(fmt.Sprintf("secret at %s saved", path))
 	return 0
 }
 
-// editSecret edits a secret, unmarshals it from YaML
-func (cmd *EditCommand) editSecret(name string) (data map[string]interface{}, err error) {
+// editSecret edits a secret, unmarshals it from YaML or with the codec of the
+// secret type; the contents of files are returned as is
+func (cmd *EditCommand) editSecret(name, kind string) (data map[string]interface{}, b []byte, err error) {
 	editor := os.ExpandEnv("$EDITOR")
 	if editor == "" {
 		cmd.ui.Warn("no $EDITOR set, defaulting to vi")
@@ -111,14 +161,19 @@ again:
 	}
 
 	// Read file contents
-	var b []byte
-	if b, err = ioutil.ReadFile(name); err != nil {
+	if b, err = ioutil.ReadFile(name); err != nil || kind == "file" {
 		return
 	}
 
 	// Unmarshal contents
-	data = make(map[string]interface{})
-	if marshalErr := yaml.Unmarshal(b, data); marshalErr != nil {
+	var marshalErr error
+	if kind != "" {
+		data, marshalErr = cmd.unmarshalTyped(kind, b)
+	} else {
+		data = make(map[string]interface{})
+		marshalErr = yaml.Unmarshal(b, data)
+	}
+	if marshalErr != nil {
 		cmd.ui.Error(marshalErr.Error())
 		if confirm("edit again?") {
 			goto again
@@ -129,17 +184,54 @@ again:
 	return
 }
 
-// readSecret loads a secret, marshals it to YaML and saves it to a temporary file
-func (cmd *EditCommand) readSecret(client *Client, path string) (name string, exists bool, err error) {
-	var secret *api.Secret
-	if secret, err = client.Logical().Read(strings.TrimLeft(path, "/")); err != nil {
-		return
+// unmarshalTyped unmarshals the contents of a typed secret and restores the
+// type marker
+func (cmd *EditCommand) unmarshalTyped(kind string, b []byte) (map[string]interface{}, error) {
+	c, err := CodecFor(kind)
+	if err != nil {
+		return nil, err
 	}
+	data, err := c.Unmarshal(b)
+	if err != nil || len(data) == 0 {
+		return data, err
+	}
+	data[CodecTypeKey] = kind
+	return data, nil
+}
 
-	exists = secret != nil
-
-	var b []byte
+// readSecret marshals a secret to YaML and saves it to a temporary file;
+// typed secrets are marshaled with the codec of their type, which is returned
+// as kind. Files are saved as is, chunked files are reassembled.
+func (cmd *EditCommand) readSecret(client *Client, path string, secret *api.Secret) (name, kind string, err error) {
+	var (
+		b      []byte
+		exists = secret != nil
+		suffix = ".yaml"
+	)
 	if exists {
+		kind, _ = secret.Data[CodecTypeKey].(string)
+	}
+	if kind == "file" {
+		if b, err = client.readFile(path, secret.Data); err != nil {
+			return
+		}
+		suffix = ".file"
+	} else if kind != "" {
+		var c Codec
+		if c, err = CodecFor(kind); err != nil {
+			return
+		}
+		data := make(map[string]interface{}, len(secret.Data))
+		for key, value := range secret.Data {
+			if key != CodecTypeKey {
+				data[key] = value
+			}
+		}
+		if b, err = c.Marshal(path, data); err != nil {
+			return
+		}
+		suffix = "." + kind
+	} else if exists {
 		if b, err = yaml.Marshal(secret.Data); err != nil {
 			return
 		}
@@ -149,7 +241,7 @@ func (cmd *EditCommand) readSecret(client *Client, path string) (name string, ex
 	}
 
 	var f *os.File
-	if f, err = tempFile(".yaml"); err != nil {
+	if f, err = tempFile(suffix); err != nil {
 		return
 	}
 
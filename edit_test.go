@@ -0,0 +1,69 @@
+package vc
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testEditor sets $EDITOR to a script that runs sed with expr on the file
+func testEditor(t *testing.T, expr string) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "vc-test")
+	if err != nil {
+		t.Skip(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	editor := filepath.Join(dir, "editor")
+	if err = ioutil.WriteFile(editor, []byte("#!/bin/sh\nsed -i '"+expr+"' \"$1\"\n"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("EDITOR", editor)
+}
+
+func TestEditCommandFile(t *testing.T) {
+	_, client := testMemoryClient()
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	info := &fileInfo{name: "app.conf", mode: 0640, modTime: mtime, user: "app", group: "app"}
+	large := bytes.Repeat([]byte("listen = 80\n"), 4)
+	if err := client.writeFile("kv/app/conf", large, info, encodingGzip, 8); err != nil {
+		t.Fatal(err)
+	}
+
+	testEditor(t, "1s/80/8080/")
+	testCommandRun(t, testCommand{
+		Factory: EditCommandFactory,
+		Args:    []string{"kv/app/conf"},
+		Code:    Success,
+		Client:  client,
+	})
+
+	secret, err := client.Read("kv/app/conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := client.readFile("kv/app/conf", secret.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := append([]byte("listen = 8080\n"), large[12:]...); !bytes.Equal(b, want) {
+		t.Fatalf("expected %q, got %q", want, b)
+	}
+	if encoding := secret.Data[fileEncodingKey]; encoding != encodingGzip {
+		t.Fatalf("expected encoding %q, got %v", encodingGzip, encoding)
+	}
+	got := newFileInfo(secret.Data)
+	if got.name != info.name || got.mode != info.mode || got.user != info.user || got.group != info.group {
+		t.Fatalf("expected metadata %+v, got %+v", info, got)
+	}
+	if got.sum != sha256Hex(b) || got.size != int64(len(b)) {
+		t.Fatalf("expected size and checksum of the edited file, got %+v", got)
+	}
+	if !got.modTime.After(mtime) {
+		t.Fatalf("expected modification time to be updated, got %s", got.modTime)
+	}
+}
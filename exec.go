@@ -0,0 +1,222 @@
+package vc
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/mitchellh/cli"
+)
+
+// execSignals are forwarded to the child process
+var execSignals = []os.Signal{
+	syscall.SIGHUP,
+	syscall.SIGINT,
+	syscall.SIGQUIT,
+	syscall.SIGTERM,
+	syscall.SIGUSR1,
+	syscall.SIGUSR2,
+	syscall.SIGWINCH,
+}
+
+// ExecCommand runs a process with secrets in its environment
+type ExecCommand struct {
+	baseCommand
+	fs       *flag.FlagSet
+	secrets  stringsValue
+	mappings stringsValue
+	prefix   string
+	upper    bool
+}
+
+func (cmd *ExecCommand) Help() string {
+	return "Usage: vc exec [<options>] -- <command> [<argument>...]\n\nOptions:\n" + defaults(cmd.fs)
+}
+
+func (cmd *ExecCommand) Run(args []string) int {
+	if err := cmd.fs.Parse(args); err != nil {
+		return SyntaxError
+	}
+	if args = cmd.fs.Args(); len(args) < 1 {
+		return Help
+	}
+
+	client, err := cmd.Client()
+	if err != nil {
+		cmd.ui.Error(err.Error())
+		return ClientError
+	}
+
+	env, err := cmd.environ(client)
+	if err != nil {
+		cmd.ui.Error(fmt.Sprintf("error: %v", err))
+		return ServerError
+	}
+
+	return cmd.exec(args, env)
+}
+
+// environ builds the environment of the child process
+func (cmd *ExecCommand) environ(client *Client) ([]string, error) {
+	vars := make(map[string]string)
+	for _, item := range os.Environ() {
+		if i := strings.IndexByte(item, '='); i > 0 {
+			vars[item[:i]] = item[i+1:]
+		}
+	}
+
+	// All keys of the secrets
+	for _, path := range cmd.secrets {
+		secret, err := client.Read(path)
+		if err != nil {
+			return nil, err
+		} else if secret == nil {
+			return nil, fmt.Errorf("%s: secret not found", path)
+		}
+		for key, value := range secret.Data {
+			if key == CodecTypeKey {
+				continue
+			}
+			name := envName(cmd.prefix+key, cmd.upper)
+			if vars[name], err = ValueString(value); err != nil {
+				return nil, fmt.Errorf("%s: key %q: %v", path, key, err)
+			}
+			Debugf("exec: %s from %s", name, path)
+		}
+	}
+
+	// Explicit mappings, NAME=path#field
+	for _, mapping := range cmd.mappings {
+		name, path, key, err := parseEnvMapping(mapping)
+		if err != nil {
+			return nil, err
+		}
+		secret, err := client.Read(path)
+		if err != nil {
+			return nil, err
+		} else if secret == nil {
+			return nil, fmt.Errorf("%s: secret not found", path)
+		}
+		value, ok := secret.Data[key]
+		if !ok {
+			return nil, fmt.Errorf("%s: key %q not found", path, key)
+		}
+		if vars[name], err = ValueString(value); err != nil {
+			return nil, fmt.Errorf("%s: key %q: %v", path, key, err)
+		}
+		Debugf("exec: %s from %s#%s", name, path, key)
+	}
+
+	env := make([]string, 0, len(vars))
+	for name, value := range vars {
+		env = append(env, name+"="+value)
+	}
+	sort.Strings(env)
+	return env, nil
+}
+
+// exec runs the command and forwards signals until it exits; returns the exit
+// status of the command
+func (cmd *ExecCommand) exec(args, env []string) int {
+	child := exec.Command(args[0], args[1:]...)
+	child.Env = env
+	child.Stdin = os.Stdin
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, execSignals...)
+	defer signal.Stop(signals)
+
+	if err := child.Start(); err != nil {
+		cmd.ui.Error(fmt.Sprintf("error: %v", err))
+		return SystemError
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				Debugf("exec: forwarding %s to %d", sig, child.Process.Pid)
+				child.Process.Signal(sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	if err := child.Wait(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode()
+		}
+		cmd.ui.Error(fmt.Sprintf("error: %v", err))
+		return SystemError
+	}
+	return Success
+}
+
+// envName converts a key to an environment variable name
+func envName(key string, upper bool) string {
+	if upper {
+		key = strings.ToUpper(key)
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+}
+
+// parseEnvMapping parses NAME=path#field; if the field is omitted, the name is
+// used as field
+func parseEnvMapping(mapping string) (name, path, field string, err error) {
+	i := strings.IndexByte(mapping, '=')
+	if i < 1 {
+		return "", "", "", fmt.Errorf("invalid mapping %q, expected NAME=path#field", mapping)
+	}
+	name, path = mapping[:i], mapping[i+1:]
+	if j := strings.LastIndexByte(path, '#'); j >= 0 {
+		path, field = path[:j], path[j+1:]
+	} else {
+		field = name
+	}
+	if path == "" || field == "" {
+		return "", "", "", fmt.Errorf("invalid mapping %q, expected NAME=path#field", mapping)
+	}
+	return
+}
+
+func (cmd *ExecCommand) Synopsis() string {
+	return "run a command with secrets in its environment"
+}
+
+func ExecCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		cmd := &ExecCommand{
+			baseCommand: baseCommand{
+				ui: ui,
+			},
+		}
+
+		cmd.fs = flag.NewFlagSet("exec", flag.ContinueOnError)
+		cmd.fs.Var(&cmd.secrets, "s", "secret path, all keys are added to the environment (may be repeated)")
+		cmd.fs.Var(&cmd.mappings, "e", "add key as variable, as NAME=path#field (may be repeated)")
+		cmd.fs.StringVar(&cmd.prefix, "p", "", "prefix for the variable names of secret keys")
+		cmd.fs.BoolVar(&cmd.upper, "u", true, "uppercase the variable names of secret keys")
+		cmd.fs.Usage = func() {
+			fmt.Print(cmd.Help())
+		}
+
+		return cmd, nil
+	}
+}
@@ -0,0 +1,77 @@
+package vc
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEnvName(t *testing.T) {
+	tests := []struct {
+		Test  string
+		Upper bool
+		Want  string
+	}{
+		{"password", true, "PASSWORD"},
+		{"db.password", true, "DB_PASSWORD"},
+		{"api-key", false, "api_key"},
+		{"APP_token", false, "APP_token"},
+	}
+	for _, test := range tests {
+		if got := envName(test.Test, test.Upper); got != test.Want {
+			t.Errorf("envName(%q, %t): expected %q, got %q", test.Test, test.Upper, test.Want, got)
+		}
+	}
+}
+
+func TestParseEnvMapping(t *testing.T) {
+	name, path, field, err := parseEnvMapping("DB_PASS=secret/app/db#password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "DB_PASS" || path != "secret/app/db" || field != "password" {
+		t.Fatalf("unexpected mapping %q %q %q", name, path, field)
+	}
+	if _, _, field, _ = parseEnvMapping("token=secret/app/api"); field != "token" {
+		t.Fatalf("expected field token, got %q", field)
+	}
+	for _, test := range []string{"secret/app/db", "=secret/app/db#key", "KEY=secret/app/db#"} {
+		if _, _, _, err = parseEnvMapping(test); err == nil {
+			t.Errorf("parseEnvMapping(%q): expected error", test)
+		}
+	}
+}
+
+func TestExecCommand(t *testing.T) {
+	_, client := testMemoryClient()
+	testWrite(t, client, "secret/app/db", map[string]interface{}{"password": "test", "port": json.Number("5432")})
+	testWrite(t, client, "kv/app/api", map[string]interface{}{"token": "secret"})
+
+	for _, test := range []testCommand{
+		testCommand{
+			Factory: ExecCommandFactory,
+			Args:    []string{"--help"},
+			Code:    Success,
+		},
+		testCommand{
+			Factory: ExecCommandFactory,
+			Args: []string{"-s", "secret/app/db", "-p", "db_", "-e", "API_TOKEN=kv/app/api#token", "--",
+				"sh", "-c", `test "$DB_PASSWORD" = test && test "$DB_PORT" = 5432 && test "$API_TOKEN" = secret`},
+			Code:   Success,
+			Client: client,
+		},
+		testCommand{
+			Factory: ExecCommandFactory,
+			Args:    []string{"-s", "secret/app/db", "--", "sh", "-c", "exit 7"},
+			Code:    7,
+			Client:  client,
+		},
+		testCommand{
+			Factory: ExecCommandFactory,
+			Args:    []string{"-s", "secret/missing", "--", "true"},
+			Code:    ServerError,
+			Client:  client,
+		},
+	} {
+		testCommandRun(t, test)
+	}
+}
@@ -0,0 +1,155 @@
+package vc
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/mitchellh/cli"
+)
+
+// ExportCommand writes a tree of secrets to an archive
+type ExportCommand struct {
+	baseCommand
+	fs       *flag.FlagSet
+	mod      string
+	format   string
+	document string
+}
+
+func (cmd *ExportCommand) Help() string {
+	return "Usage: vc export [<options>] <secret path>\n\nOptions:\n" + defaults(cmd.fs)
+}
+
+func (cmd *ExportCommand) Run(args []string) int {
+	if err := cmd.fs.Parse(args); err != nil {
+		return SyntaxError
+	}
+	if args = cmd.fs.Args(); len(args) != 1 {
+		return Help
+	}
+
+	if mode, err := strconv.ParseInt(cmd.mod, 8, 32); err != nil {
+		cmd.ui.Error("error: invalid mode: " + err.Error())
+		return SyntaxError
+	} else {
+		cmd.mode = os.FileMode(mode)
+	}
+
+	format := cmd.format
+	if format == "" {
+		format = archiveFormat(cmd.out)
+	}
+	switch format {
+	case archiveTar, archiveJSON, archiveYAML:
+	default:
+		cmd.ui.Error(fmt.Sprintf("error: unsupported format %q", format))
+		return SyntaxError
+	}
+
+	client, err := cmd.Client()
+	if err != nil {
+		cmd.ui.Error(err.Error())
+		return ClientError
+	}
+
+	a, err := cmd.export(client, args[0])
+	if err != nil {
+		cmd.ui.Error(err.Error())
+		return ServerError
+	}
+	if len(a.Secrets) == 0 {
+		cmd.ui.Error(fmt.Sprintf("no secrets at %q", args[0]))
+		return SyntaxError
+	}
+
+	w, err := cmd.sealed(SafeOutputWriter(cmd.out, cmd.mode))
+	if err != nil {
+		cmd.ui.Error(fmt.Sprintf("error: %v", err))
+		return SyntaxError
+	}
+	if err = writeArchive(w, a, format, cmd.document); err != nil {
+		cmd.ui.Error(fmt.Sprintf("error: %v", err))
+		return CodecError
+	}
+	if w != os.Stdout {
+		if err = w.Close(); err != nil {
+			cmd.ui.Error(fmt.Sprintf("error: %v", err))
+			return SystemError
+		}
+	}
+	if !stdoutName[cmd.out] {
+		cmd.ui.Info(fmt.Sprintf("exported %d secrets to %s", len(a.Secrets), cmd.out))
+	}
+
+	return Success
+}
+
+// export reads all secrets below root; if root itself is a secret, the paths
+// in the archive are relative to its parent
+func (cmd *ExportCommand) export(client *Client, root string) (*archive, error) {
+	paths, err := client.secretPaths(root)
+	if err != nil {
+		return nil, err
+	}
+
+	root = client.abspath(root)
+	a := &archive{
+		Version: archiveVersion,
+		Root:    root,
+		Created: time.Now(),
+		Secrets: make(map[string]map[string]interface{}),
+	}
+	if len(paths) > 0 && paths[0] == "" {
+		a.Root = path.Dir(root)
+	}
+
+	for _, rel := range paths {
+		name := path.Join(root, rel)
+		secret, err := client.Read(name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		} else if secret == nil {
+			// Removed while walking
+			continue
+		}
+		key, err := filepath.Rel(a.Root, name)
+		if err != nil {
+			return nil, err
+		}
+		Debugf("export: %s as %s", name, key)
+		a.Secrets[key] = secret.Data
+	}
+
+	return a, nil
+}
+
+func (cmd *ExportCommand) Synopsis() string {
+	return "export secrets to an archive"
+}
+
+func ExportCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		cmd := &ExportCommand{
+			baseCommand: baseCommand{
+				ui: ui,
+			},
+		}
+
+		cmd.fs = flag.NewFlagSet("export", flag.ContinueOnError)
+		cmd.fs.StringVar(&cmd.format, "format", "", "archive format: tar, json or yaml (default: by output extension, or json)")
+		cmd.fs.StringVar(&cmd.document, "document", "json", "codec for the documents in a tar archive: json or yaml")
+		cmd.fs.StringVar(&cmd.mod, "m", "0600", "output mode")
+		cmd.fs.StringVar(&cmd.out, "o", "", "output (default stdout)")
+		cmd.encryptFlags(cmd.fs)
+		cmd.fs.Usage = func() {
+			fmt.Print(cmd.Help())
+		}
+
+		return cmd, nil
+	}
+}
@@ -1,13 +1,12 @@
 package vc
 
 import (
-	"bytes"
-	"encoding/base64"
 	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -23,12 +22,21 @@ type FileCommand struct {
 	key           string
 	mod           string
 	encoding      string
+	kind          string
+	chunkSize     int
+	preserve      bool
+	compress      bool
 	ignoreMissing bool
 	force         bool
+	decrypt       bool
+	decryptKey    string
 }
 
 func (cmd *FileCommand) Help() string {
-	return "Usage: vc file <get|put> <secret path> [<file path>]\n\nOptions:\n" + defaults(cmd.fs)
+	return "Usage: vc file get <secret path> [<file path>]\n" +
+		"       vc file get <secret path> [... <secret path>] <directory>\n" +
+		"       vc file put <secret path> [<file path>]\n" +
+		"       vc file put <secret directory>/ <file path> [... <file path>]\n\nOptions:\n" + defaults(cmd.fs)
 }
 
 func (cmd *FileCommand) Run(args []string) int {
@@ -48,9 +56,13 @@ func (cmd *FileCommand) Run(args []string) int {
 	var err error
 	switch cmd.sub {
 	case "get":
-		err = cmd.runGet(args[0], args[1])
+		err = cmd.runGets(args[:len(args)-1], args[len(args)-1])
 	case "put":
-		err = cmd.runPut(args[0], args[1])
+		if cmd.compress && cmd.encoding != encodingGzip && cmd.encoding != encodingZstd {
+			cmd.ui.Error(fmt.Sprintf("error: unsupported encoding %q", cmd.encoding))
+			return 1
+		}
+		err = cmd.runPuts(args[0], args[1:])
 	default:
 		return cli.RunResultHelp
 	}
@@ -63,6 +75,33 @@ func (cmd *FileCommand) Run(args []string) int {
 	return 0
 }
 
+// runGets gets one or more files from Vault; if multiple secrets are
+// requested, name must be a directory
+func (cmd *FileCommand) runGets(patterns []string, name string) (err error) {
+	var client *Client
+	if client, err = cmd.Client(); err != nil {
+		return
+	}
+
+	var paths []string
+	if paths, err = cmd.globs(client, patterns); err != nil {
+		return
+	}
+	if len(paths) == 1 {
+		return cmd.runGet(paths[0], name)
+	}
+
+	if info, statErr := os.Stat(name); statErr != nil || !info.IsDir() {
+		return fmt.Errorf("%s: not a directory", name)
+	}
+	for _, path := range paths {
+		if err = cmd.runGet(path, filepath.Join(name, filepath.Base(path))); err != nil {
+			return
+		}
+	}
+	return
+}
+
 // runGet gets a file from Vault
 func (cmd *FileCommand) runGet(path, name string) (err error) {
 	if !cmd.force && name != "" && name != "-" {
@@ -88,7 +127,7 @@ func (cmd *FileCommand) runGet(path, name string) (err error) {
 	}
 
 	var secret *api.Secret
-	if secret, err = client.Logical().Read(strings.TrimLeft(path, "/")); err != nil {
+	if secret, err = client.Read(path); err != nil {
 		return
 	}
 	if secret == nil {
@@ -101,38 +140,106 @@ func (cmd *FileCommand) runGet(path, name string) (err error) {
 	kind, ok := secret.Data["__TYPE__"].(string)
 	if !ok {
 		return fmt.Errorf("secret at %q has no type marker", path)
-	} else if kind != "file" {
-		return fmt.Errorf("secret at %q is not a file", path)
 	}
 
-	contents, ok := secret.Data["contents"].(string)
-	if !ok {
-		return fmt.Errorf("secret at %q has no content", path)
+	var (
+		data []byte
+		info = new(fileInfo)
+	)
+	if kind == "file" {
+		info = newFileInfo(secret.Data)
+		if data, err = client.readFile(path, secret.Data); err == ErrFileContentsMissing {
+			return fmt.Errorf("secret at %q has no content", path)
+		} else if err != nil {
+			return
+		}
+	} else {
+		// Other typed secrets are encoded by their codec
+		var codec Codec
+		if codec, err = CodecFor(kind); err != nil {
+			return fmt.Errorf("secret at %q is not a file: %v", path, err)
+		}
+		delete(secret.Data, CodecTypeKey)
+		if data, err = codec.Marshal(path, secret.Data); err != nil {
+			return
+		}
 	}
 
-	var data []byte
-	if data, err = base64.StdEncoding.DecodeString(contents); err != nil {
+	if name == "" || name == "-" {
+		_, err = os.Stdout.Write(data)
+		return
+	}
+	if err = ioutil.WriteFile(name, data, cmd.mode); err != nil || !cmd.preserve {
 		return
 	}
 
-	if name == "" || name == "-" {
-		_, err = os.Stdout.Write(data)
-	} else {
-		err = ioutil.WriteFile(name, data, cmd.mode)
+	// An explicit mode takes precedence over the stored mode
+	if info.mode == 0 || cmd.modeSet() {
+		info.mode = cmd.mode
+	}
+	return info.restore(name)
+}
+
+// fileEncoding returns the compression for put, if enabled
+func (cmd *FileCommand) fileEncoding() string {
+	if cmd.compress {
+		return cmd.encoding
+	}
+	return ""
+}
+
+// modeSet checks if the mode was given on the command line
+func (cmd *FileCommand) modeSet() (set bool) {
+	cmd.fs.Visit(func(f *flag.Flag) {
+		if f.Name == "m" {
+			set = true
+		}
+	})
+	return
+}
+
+// runPuts puts one or more files in Vault; if multiple files are given or
+// path ends with a slash, the files are stored in the secret directory path
+func (cmd *FileCommand) runPuts(path string, names []string) (err error) {
+	if len(names) == 1 && !strings.HasSuffix(path, "/") {
+		return cmd.runPut(path, names[0])
 	}
 
+	for _, name := range names {
+		if name == "" || name == "-" {
+			return fmt.Errorf("can't store stdin in secret directory %s", path)
+		}
+		if err = cmd.runPut(filepath.Join(path, filepath.Base(name)), name); err != nil {
+			return
+		}
+	}
 	return
 }
 
 // runPut puts a file in Vault
 func (cmd *FileCommand) runPut(path, name string) (err error) {
-	var b []byte
+	var (
+		b    []byte
+		info *fileInfo
+	)
 	if name == "" || name == "-" {
 		if b, err = ioutil.ReadAll(os.Stdin); err != nil {
 			return
 		}
-	} else if b, err = ioutil.ReadFile(name); err != nil {
-		return
+	} else {
+		var stat os.FileInfo
+		if stat, err = os.Stat(name); err != nil {
+			return
+		}
+		if b, err = ioutil.ReadFile(name); err != nil {
+			return
+		}
+		info = localFileInfo(stat)
+	}
+	if cmd.decrypt {
+		if b, err = cmd.unseal(b, cmd.decryptKey); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
 	}
 
 	var client *Client
@@ -141,7 +248,7 @@ func (cmd *FileCommand) runPut(path, name string) (err error) {
 	}
 
 	if !cmd.force {
-		if secret, _ := client.Logical().Read(strings.TrimLeft(path, "/")); secret != nil {
+		if secret, _ := client.Read(path); secret != nil {
 			if !IsTerminal(os.Stdout.Fd()) || name == "" || name == "-" {
 				return fmt.Errorf("secret at %q already exists", path)
 			}
@@ -151,22 +258,27 @@ func (cmd *FileCommand) runPut(path, name string) (err error) {
 		}
 	}
 
-	out := new(bytes.Buffer)
-	var breaker lineBreaker
-	breaker.out = out
+	if cmd.kind != "" && cmd.kind != "file" {
+		return cmd.runPutTyped(client, path, name, b)
+	}
 
-	b64 := base64.NewEncoder(base64.StdEncoding, &breaker)
-	if _, err = b64.Write(b); err != nil {
-		return err
+	return client.writeFile(path, b, info, cmd.fileEncoding(), cmd.chunkSize)
+}
+
+// runPutTyped decodes a file with the codec for its type and stores the result
+func (cmd *FileCommand) runPutTyped(client *Client, path, name string, b []byte) (err error) {
+	var codec Codec
+	if codec, err = CodecFor(cmd.kind); err != nil {
+		return
 	}
-	b64.Close()
-	breaker.Close()
 
-	_, err = client.Logical().Write(strings.TrimLeft(path, "/"), map[string]interface{}{
-		CodecTypeKey: "file",
-		"contents":   out.String(),
-	})
+	var data map[string]interface{}
+	if data, err = codec.Unmarshal(b); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	data[CodecTypeKey] = cmd.kind
 
+	_, err = client.Write(path, data)
 	return
 }
 
@@ -186,7 +298,18 @@ func FileCommandFactory(ui cli.Ui, sub string) cli.CommandFactory {
 		cmd.fs = flag.NewFlagSet("file", flag.ContinueOnError)
 		cmd.fs.BoolVar(&cmd.ignoreMissing, "i", false, "ignore missing key")
 		cmd.fs.BoolVar(&cmd.force, "f", false, "force overwrite")
-		cmd.fs.StringVar(&cmd.mod, "m", "0600", "output mode (for put)")
+		cmd.fs.StringVar(&cmd.mod, "m", "0600", "output mode, overrides the stored mode (for get)")
+		if sub == "get" {
+			cmd.fs.BoolVar(&cmd.preserve, "p", true, "restore the stored mode, ownership and modification time")
+		}
+		if sub == "put" {
+			cmd.fs.StringVar(&cmd.kind, "t", "file", "type of the secret, decoded with the codec for the type (such as pem)")
+			cmd.fs.BoolVar(&cmd.compress, "z", false, "compress the file")
+			cmd.fs.StringVar(&cmd.encoding, "encoding", encodingGzip, "compression used by -z: "+encodingGzip+" or "+encodingZstd)
+			cmd.fs.IntVar(&cmd.chunkSize, "chunk-size", fileChunkSize, "files larger than this many bytes are stored in chunks")
+			cmd.fs.BoolVar(&cmd.decrypt, "decrypt", false, "decrypt the file before storing")
+			cmd.fs.StringVar(&cmd.decryptKey, "k", "", "private key file (for files encrypted to public keys)")
+		}
 		cmd.fs.Usage = func() {
 			fmt.Print(cmd.Help())
 		}
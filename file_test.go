@@ -0,0 +1,91 @@
+package vc
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestFileCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vc-test")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		local = filepath.Join(dir, "app.conf")
+		mtime = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	)
+	if err = ioutil.WriteFile(local, []byte("listen = 8080\n"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chmod(local, 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chtimes(local, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	_, client := testMemoryClient()
+	testCommandRun(t, testCommand{
+		Factory: func(ui cli.Ui) cli.CommandFactory { return FileCommandFactory(ui, "put") },
+		Args:    []string{"kv/app.conf", local},
+		Code:    Success,
+		Client:  client,
+	})
+
+	secret, err := client.Read("kv/app.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := newFileInfo(secret.Data)
+	if info.name != "app.conf" || info.size != 14 || info.mode != 0640 || !info.modTime.Equal(mtime) || info.sum != sha256Hex([]byte("listen = 8080\n")) {
+		t.Fatalf("unexpected metadata %+v", info)
+	}
+
+	copied := filepath.Join(dir, "copy.conf")
+	testCommandRun(t, testCommand{
+		Factory: func(ui cli.Ui) cli.CommandFactory { return FileCommandFactory(ui, "get") },
+		Args:    []string{"kv/app.conf", copied},
+		Code:    Success,
+		Client:  client,
+	})
+	if stat, err := os.Stat(copied); err != nil {
+		t.Fatal(err)
+	} else if stat.Mode() != 0640 || !stat.ModTime().Equal(mtime) {
+		t.Fatalf("expected mode %s and mtime %s, got %s and %s", os.FileMode(0640), mtime, stat.Mode(), stat.ModTime())
+	}
+
+	// Explicit mode
+	testCommandRun(t, testCommand{
+		Factory: func(ui cli.Ui) cli.CommandFactory { return FileCommandFactory(ui, "get") },
+		Args:    []string{"-f", "-m", "0600", "kv/app.conf", copied},
+		Code:    Success,
+		Client:  client,
+	})
+	if stat, err := os.Stat(copied); err != nil {
+		t.Fatal(err)
+	} else if stat.Mode() != 0600 {
+		t.Fatalf("expected mode %s, got %s", os.FileMode(0600), stat.Mode())
+	}
+
+	// Corrupted contents are refused
+	secret.Data[fileContentsKey] = encodeContents([]byte("listen = 8443\n"))
+	testWrite(t, client, "kv/app.conf", secret.Data)
+	testCommandRun(t, testCommand{
+		Factory: func(ui cli.Ui) cli.CommandFactory { return FileCommandFactory(ui, "get") },
+		Args:    []string{"-f", "kv/app.conf", copied},
+		Code:    1,
+		Client:  client,
+	})
+	if b, err := ioutil.ReadFile(copied); err != nil {
+		t.Fatal(err)
+	} else if string(b) != "listen = 8080\n" {
+		t.Fatalf("expected file to be unchanged, got %q", b)
+	}
+}
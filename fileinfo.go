@@ -0,0 +1,137 @@
+package vc
+
+import (
+	"os"
+	"os/user"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Metadata keys of file secrets
+const (
+	fileNameKey  = "name"
+	fileModeKey  = "mode"
+	fileMtimeKey = "mtime"
+	fileUserKey  = "user"
+	fileGroupKey = "group"
+)
+
+// fileInfo is the metadata stored with a file secret, it implements
+// os.FileInfo
+type fileInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+	user    string
+	group   string
+	sum     string
+	chunks  int
+}
+
+// newFileInfo parses the metadata of file secret data; missing metadata is
+// left at its zero value
+func newFileInfo(data map[string]interface{}) *fileInfo {
+	info := new(fileInfo)
+	info.name, _ = data[fileNameKey].(string)
+	if size, err := jsonInt(data[fileSizeKey]); err == nil {
+		info.size = size
+	} else if b, err := decodeContents(data); err == nil {
+		// Files stored by older versions have no size
+		info.size = int64(len(b))
+	}
+	if s, ok := data[fileModeKey].(string); ok {
+		if mode, err := strconv.ParseUint(s, 8, 32); err == nil {
+			info.mode = os.FileMode(mode) & os.ModePerm
+		}
+	}
+	if s, ok := data[fileMtimeKey].(string); ok {
+		info.modTime, _ = time.Parse(time.RFC3339Nano, s)
+	}
+	info.user, _ = data[fileUserKey].(string)
+	info.group, _ = data[fileGroupKey].(string)
+	info.sum, _ = data[fileSHA256Key].(string)
+	if chunks, ok := data[fileChunksKey].([]interface{}); ok {
+		info.chunks = len(chunks)
+	}
+	return info
+}
+
+// localFileInfo takes the metadata of a local file
+func localFileInfo(stat os.FileInfo) *fileInfo {
+	info := &fileInfo{
+		name:    stat.Name(),
+		size:    stat.Size(),
+		mode:    stat.Mode() & os.ModePerm,
+		modTime: stat.ModTime(),
+	}
+	if sys, ok := stat.Sys().(*syscall.Stat_t); ok {
+		uid, gid := strconv.FormatUint(uint64(sys.Uid), 10), strconv.FormatUint(uint64(sys.Gid), 10)
+		if usr, err := user.LookupId(uid); err == nil {
+			info.user = usr.Username
+		}
+		if grp, err := user.LookupGroupId(gid); err == nil {
+			info.group = grp.Name
+		}
+	}
+	return info
+}
+
+// update stores the metadata in file secret data
+func (i *fileInfo) update(data map[string]interface{}) {
+	data[fileSizeKey] = i.size
+	data[fileSHA256Key] = i.sum
+	for key, value := range map[string]string{
+		fileNameKey:  i.name,
+		fileUserKey:  i.user,
+		fileGroupKey: i.group,
+	} {
+		if value != "" {
+			data[key] = value
+		}
+	}
+	if i.mode != 0 {
+		data[fileModeKey] = "0" + strconv.FormatUint(uint64(i.mode), 8)
+	}
+	if !i.modTime.IsZero() {
+		data[fileMtimeKey] = i.modTime.UTC().Format(time.RFC3339Nano)
+	}
+}
+
+// restore the mode, ownership and modification time of a local file; changing
+// ownership is skipped if the user or group is unknown or not permitted
+func (i *fileInfo) restore(name string) error {
+	if i.mode != 0 {
+		if err := os.Chmod(name, i.mode); err != nil {
+			return err
+		}
+	}
+
+	uid, gid := -1, -1
+	if usr, err := user.Lookup(i.user); i.user != "" && err == nil {
+		uid, _ = strconv.Atoi(usr.Uid)
+	}
+	if grp, err := user.LookupGroup(i.group); i.group != "" && err == nil {
+		gid, _ = strconv.Atoi(grp.Gid)
+	}
+	if uid != -1 || gid != -1 {
+		if err := os.Chown(name, uid, gid); os.IsPermission(err) {
+			Debugf("file: %s: not permitted to change ownership to %s:%s", name, i.user, i.group)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if !i.modTime.IsZero() {
+		return os.Chtimes(name, i.modTime, i.modTime)
+	}
+	return nil
+}
+
+func (i *fileInfo) Name() string       { return i.name }
+func (i *fileInfo) Size() int64        { return i.size }
+func (i *fileInfo) Mode() os.FileMode  { return i.mode }
+func (i *fileInfo) ModTime() time.Time { return i.modTime }
+func (i *fileInfo) IsDir() bool        { return false }
+func (i *fileInfo) Sys() interface{}   { return nil }
@@ -0,0 +1,351 @@
+package vc
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+// FindCommand searches the secret hierarchy with predicates and actions
+type FindCommand struct {
+	baseCommand
+	stdout io.Writer
+}
+
+// findItem is a directory or secret visited by find
+type findItem struct {
+	client *Client
+	path   string
+	info   os.FileInfo
+	depth  int
+	data   map[string]interface{}
+	loaded bool
+}
+
+// secret returns the data of the secret, nil for directories
+func (item *findItem) secret() (map[string]interface{}, error) {
+	if item.info.IsDir() || item.loaded {
+		return item.data, nil
+	}
+	secret, err := item.client.Read(item.path)
+	if err != nil {
+		return nil, err
+	}
+	item.loaded = true
+	if secret != nil {
+		item.data = secret.Data
+	}
+	return item.data, nil
+}
+
+// findPredicate tests an item; actions are predicates with side effects
+type findPredicate func(*findItem) (bool, error)
+
+// findExpression is a parsed find command line
+type findExpression struct {
+	roots      []string
+	predicates []findPredicate
+	minDepth   int
+	maxDepth   int
+	actions    bool
+}
+
+func (cmd *FindCommand) Help() string {
+	return `Usage: vc find <secret path> [... <secret path>] [<expression>]
+
+Tests:
+  -type d|s|<type>   directory, secret, or secret with the __TYPE__ marker
+  -name <pattern>    base name matches the glob pattern
+  -path <pattern>    path matches the glob pattern
+  -haskey <key>      secret has the key
+  -mount <type>      mount type, such as kv, kv2 or generic
+  -mindepth <n>      only test items at least n levels below the path
+  -maxdepth <n>      descend at most n levels below the path
+  ! <test>, -not     negate the following test
+
+Actions:
+  -print             print the path, followed by a newline (default)
+  -print0            print the path, followed by a NUL character
+  -exec <command> ;  run command, {} is replaced with the path; true if it exits
+                     with status 0
+
+All tests and actions must be true for an item, evaluated from left to right.
+`
+}
+
+func (cmd *FindCommand) Run(args []string) int {
+	if len(args) == 0 {
+		return Help
+	}
+
+	expr, err := cmd.parse(args)
+	if err != nil {
+		cmd.ui.Error(fmt.Sprintf("error: %v", err))
+		return SyntaxError
+	}
+
+	client, err := cmd.Client()
+	if err != nil {
+		cmd.ui.Error(err.Error())
+		return ClientError
+	}
+
+	var ret int
+	for _, root := range expr.roots {
+		root = client.abspath(root)
+		err = client.WalkDir(root, func(name string, info os.FileInfo, err error) error {
+			if err != nil {
+				cmd.ui.Error(fmt.Sprintf("%s: %v", name, err))
+				ret = ServerError
+				return nil
+			}
+			if isChunkPath(name) {
+				return SkipDir
+			}
+
+			item := &findItem{client: client, path: name, info: info, depth: findDepth(root, name)}
+			if item.depth >= expr.minDepth {
+				if err := expr.eval(item); err != nil {
+					cmd.ui.Error(fmt.Sprintf("%s: %v", name, err))
+					ret = ServerError
+				}
+			}
+			if info.IsDir() && expr.maxDepth >= 0 && item.depth >= expr.maxDepth {
+				return SkipDir
+			}
+			return nil
+		})
+		if err != nil {
+			cmd.ui.Error(fmt.Sprintf("%s: %v", root, err))
+			ret = ServerError
+		}
+	}
+	return ret
+}
+
+// eval the predicates from left to right, stopping at the first false one
+func (expr *findExpression) eval(item *findItem) error {
+	for _, predicate := range expr.predicates {
+		if ok, err := predicate(item); err != nil || !ok {
+			return err
+		}
+	}
+	return nil
+}
+
+// parse the roots and expression
+func (cmd *FindCommand) parse(args []string) (*findExpression, error) {
+	expr := &findExpression{maxDepth: -1}
+	for len(args) > 0 && !strings.HasPrefix(args[0], "-") && args[0] != "!" {
+		expr.roots = append(expr.roots, args[0])
+		args = args[1:]
+	}
+	if len(expr.roots) == 0 {
+		return nil, errors.New("no paths given")
+	}
+
+	var not bool
+	for len(args) > 0 {
+		arg := args[0]
+		args = args[1:]
+
+		// Options and tests with an argument
+		var value string
+		switch arg {
+		case "-type", "-name", "-path", "-haskey", "-mount", "-mindepth", "-maxdepth":
+			if len(args) == 0 {
+				return nil, fmt.Errorf("%s requires an argument", arg)
+			}
+			value, args = args[0], args[1:]
+		}
+
+		var predicate findPredicate
+		switch arg {
+		case "!", "-not":
+			not = !not
+			continue
+
+		case "-mindepth", "-maxdepth":
+			depth, err := strconv.Atoi(value)
+			if err != nil || depth < 0 {
+				return nil, fmt.Errorf("%s: invalid depth %q", arg, value)
+			}
+			if arg == "-mindepth" {
+				expr.minDepth = depth
+			} else {
+				expr.maxDepth = depth
+			}
+			continue
+
+		case "-type":
+			predicate = findType(value)
+
+		case "-name", "-path":
+			compile := globSegment
+			if arg == "-path" {
+				compile = globPath
+			}
+			re, err := compile(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", arg, err)
+			}
+			base := arg == "-name"
+			predicate = func(item *findItem) (bool, error) {
+				name := item.path
+				if base {
+					name = path.Base(name)
+				}
+				return re.MatchString(name), nil
+			}
+
+		case "-haskey":
+			key := value
+			predicate = func(item *findItem) (bool, error) {
+				data, err := item.secret()
+				if err != nil {
+					return false, err
+				}
+				_, ok := data[key]
+				return ok, nil
+			}
+
+		case "-mount":
+			predicate = findMount(value)
+
+		case "-print", "-print0":
+			end := "\n"
+			if arg == "-print0" {
+				end = "\x00"
+			}
+			predicate = func(item *findItem) (bool, error) {
+				_, err := io.WriteString(cmd.output(), item.path+end)
+				return err == nil, err
+			}
+			expr.actions = true
+
+		case "-exec":
+			var command []string
+			for len(args) > 0 && args[0] != ";" {
+				command, args = append(command, args[0]), args[1:]
+			}
+			if len(args) == 0 || len(command) == 0 {
+				return nil, errors.New("-exec requires a command terminated by ;")
+			}
+			args = args[1:]
+			predicate = cmd.exec(command)
+			expr.actions = true
+
+		default:
+			return nil, fmt.Errorf("unknown predicate %q", arg)
+		}
+
+		if not {
+			negated := predicate
+			predicate = func(item *findItem) (bool, error) {
+				ok, err := negated(item)
+				return !ok, err
+			}
+			not = false
+		}
+		expr.predicates = append(expr.predicates, predicate)
+	}
+	if not {
+		return nil, errors.New("expected a test after negation")
+	}
+
+	if !expr.actions {
+		expr.predicates = append(expr.predicates, func(item *findItem) (bool, error) {
+			_, err := io.WriteString(cmd.output(), item.path+"\n")
+			return err == nil, err
+		})
+	}
+	return expr, nil
+}
+
+// findType tests for directories (d), secrets (s) or the type marker
+func findType(kind string) findPredicate {
+	return func(item *findItem) (bool, error) {
+		switch kind {
+		case "d":
+			return item.info.IsDir(), nil
+		case "s":
+			return !item.info.IsDir(), nil
+		}
+		data, err := item.secret()
+		if err != nil {
+			return false, err
+		}
+		marker, _ := data[CodecTypeKey].(string)
+		return marker == kind, nil
+	}
+}
+
+// findMount tests the type of the mount, optionally with the KV version
+func findMount(kind string) findPredicate {
+	return func(item *findItem) (bool, error) {
+		_, mount, err := item.client.mountFor(item.path)
+		if err != nil || mount == nil {
+			return false, err
+		}
+		return mount.Type == kind || mountType(mount) == kind, nil
+	}
+}
+
+// exec returns an action that runs command with {} replaced by the path
+func (cmd *FindCommand) exec(command []string) findPredicate {
+	return func(item *findItem) (bool, error) {
+		args := make([]string, len(command))
+		for i, arg := range command {
+			args[i] = strings.Replace(arg, "{}", item.path, -1)
+		}
+		Debugf("find: exec %q", args)
+		child := exec.Command(args[0], args[1:]...)
+		child.Stdin = os.Stdin
+		child.Stdout = cmd.output()
+		child.Stderr = os.Stderr
+		if err := child.Run(); err != nil {
+			if _, ok := err.(*exec.ExitError); ok {
+				return false, nil
+			}
+			return false, err
+		}
+		return true, nil
+	}
+}
+
+func (cmd *FindCommand) output() io.Writer {
+	if cmd.stdout == nil {
+		return os.Stdout
+	}
+	return cmd.stdout
+}
+
+// findDepth returns the number of levels name is below root
+func findDepth(root, name string) int {
+	rel := strings.Trim(strings.TrimPrefix(name, root), "/")
+	if rel == "" {
+		return 0
+	}
+	return strings.Count(rel, "/") + 1
+}
+
+func (cmd *FindCommand) Synopsis() string {
+	return "search secrets by name, type, key and depth"
+}
+
+func FindCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return &FindCommand{
+			baseCommand: baseCommand{
+				ui: ui,
+			},
+		}, nil
+	}
+}
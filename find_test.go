@@ -0,0 +1,81 @@
+package vc
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestFindCommand(t *testing.T) {
+	_, client := testMemoryClient()
+	testWrite(t, client, "secret/app/db", map[string]interface{}{"password": "secret"})
+	testWrite(t, client, "secret/app/tls/server.pem", map[string]interface{}{CodecTypeKey: "pem", "certificate": "-"})
+	testWrite(t, client, "secret/app/tls/deep/ca.pem", map[string]interface{}{CodecTypeKey: "pem", "password": "secret"})
+	testWrite(t, client, "kv/app/api", map[string]interface{}{"password": "secret"})
+	if err := client.writeFile("secret/app/conf", []byte("listen = 80\n"), nil, encodingGzip, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"secret/app", "-type", "s"}, "/secret/app/conf\n/secret/app/db\n/secret/app/tls/deep/ca.pem\n/secret/app/tls/server.pem\n"},
+		{[]string{"secret/app", "-type", "d"}, "/secret/app\n/secret/app/tls\n/secret/app/tls/deep\n"},
+		{[]string{"secret/app", "-type", "file"}, "/secret/app/conf\n"},
+		{[]string{"secret/app", "-type", "pem", "-name", "*.pem", "-maxdepth", "2"}, "/secret/app/tls/server.pem\n"},
+		{[]string{"secret/app", "-haskey", "password", "-print0"}, "/secret/app/db\x00/secret/app/tls/deep/ca.pem\x00"},
+		{[]string{"secret/app", "-type", "s", "!", "-haskey", "password"}, "/secret/app/conf\n/secret/app/tls/server.pem\n"},
+		{[]string{"secret/app", "-mindepth", "2", "-path", "*/tls/*"}, "/secret/app/tls/deep\n/secret/app/tls/deep/ca.pem\n/secret/app/tls/server.pem\n"},
+		{[]string{"secret/", "kv/", "-mount", "kv2", "-type", "s"}, "/kv/app/api\n"},
+		{[]string{"secret/app/db", "-exec", "echo", "found", "{}", ";"}, "found /secret/app/db\n"},
+		{[]string{"secret/app", "-type", "s", "-exec", "false", ";", "-print"}, ""},
+	} {
+		t.Run(strings.Join(test.args, " "), func(t *testing.T) {
+			var (
+				buf = new(bytes.Buffer)
+				cmd = &FindCommand{stdout: buf}
+			)
+			cmd.ui = new(cli.MockUi)
+			cmd.setClient(client)
+			if code := cmd.Run(test.args); code != Success {
+				t.Fatalf("expected return code %d, got %d", Success, code)
+			}
+			if buf.String() != test.want {
+				t.Fatalf("expected output %q, got %q", test.want, buf.String())
+			}
+		})
+	}
+
+	for _, test := range []testCommand{
+		testCommand{
+			Factory: FindCommandFactory,
+			Code:    SyntaxError,
+		},
+		testCommand{
+			Factory: FindCommandFactory,
+			Args:    []string{"-type", "d"},
+			Code:    SyntaxError,
+		},
+		testCommand{
+			Factory: FindCommandFactory,
+			Args:    []string{"secret/", "-maxdepth", "x"},
+			Code:    SyntaxError,
+		},
+		testCommand{
+			Factory: FindCommandFactory,
+			Args:    []string{"secret/", "-exec", "true"},
+			Code:    SyntaxError,
+		},
+		testCommand{
+			Factory: FindCommandFactory,
+			Args:    []string{"secret/", "-bogus"},
+			Code:    SyntaxError,
+		},
+	} {
+		test.Client = client
+		testCommandRun(t, test)
+	}
+}
@@ -0,0 +1,321 @@
+package vc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/BurntSushi/toml"
+	ini "gopkg.in/ini.v1"
+)
+
+// Output formats for secret data
+const (
+	formatJSON       = "json"
+	formatYAML       = "yaml"
+	formatDotenv     = "dotenv"
+	formatExport     = "export"
+	formatProperties = "properties"
+	formatTOML       = "toml"
+	formatINI        = "ini"
+)
+
+// formatters marshal secret data in an output format
+var formatters = map[string]func(map[string]interface{}) ([]byte, error){
+	formatJSON:       formatDataJSON,
+	formatYAML:       formatDataYAML,
+	formatDotenv:     formatDataDotenv,
+	formatExport:     formatDataExport,
+	formatProperties: formatDataProperties,
+	formatTOML:       formatDataTOML,
+	formatINI:        FormatINI,
+}
+
+// formatNames returns the names of the supported output formats
+func formatNames() []string {
+	names := make([]string, 0, len(formatters))
+	for name := range formatters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// formatData marshals secret data in an output format
+func formatData(format string, data map[string]interface{}) ([]byte, error) {
+	formatter, ok := formatters[format]
+	if !ok {
+		return nil, fmt.Errorf("vc: unsupported format %q", format)
+	}
+	return formatter(data)
+}
+
+func formatDataJSON(data map[string]interface{}) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// formatDataYAML uses the yaml codec, which is only available if vc is built
+// with the yaml build tag
+func formatDataYAML(data map[string]interface{}) ([]byte, error) {
+	c, err := CodecFor(formatYAML)
+	if err != nil {
+		return nil, err
+	}
+	return c.Marshal("", data)
+}
+
+func formatDataTOML(data map[string]interface{}) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := toml.NewEncoder(buf).Encode(TypedData(data)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// FormatINI formats data as INI; nested maps are written as sections, the
+// keys of deeper nested maps are joined with a dot
+func FormatINI(data map[string]interface{}) ([]byte, error) {
+	var (
+		file     = ini.Empty()
+		keys     = make([]string, 0, len(data))
+		sections []string
+	)
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if key == CodecTypeKey {
+			continue
+		}
+		if _, ok := data[key].(map[string]interface{}); ok {
+			sections = append(sections, key)
+			continue
+		}
+		s, err := ValueString(data[key])
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %v", key, err)
+		}
+		if _, err = file.Section(ini.DefaultSection).NewKey(key, s); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, name := range sections {
+		flat := make(map[string]string)
+		if err := Flatten(flat, "", ".", data[name].(map[string]interface{})); err != nil {
+			return nil, fmt.Errorf("section %q: %v", name, err)
+		}
+		keys = keys[:0]
+		for key := range flat {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		section, err := file.NewSection(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			if _, err = section.NewKey(key, flat[key]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	if _, err := file.WriteTo(buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// formatDataDotenv formats data as KEY=value lines; values are double quoted
+// if required
+func formatDataDotenv(data map[string]interface{}) ([]byte, error) {
+	return formatFlat(data, "_", func(key, value string) string {
+		return envName(key, false) + "=" + QuoteDotenv(value) + "\n"
+	})
+}
+
+// formatDataExport formats data as shell export statements
+func formatDataExport(data map[string]interface{}) ([]byte, error) {
+	return formatFlat(data, "_", func(key, value string) string {
+		return "export " + envName(key, false) + "=" + quoteShell(value) + "\n"
+	})
+}
+
+// formatDataProperties formats data as Java properties
+func formatDataProperties(data map[string]interface{}) ([]byte, error) {
+	return formatFlat(data, ".", func(key, value string) string {
+		return EscapeProperties(key, true) + "=" + EscapeProperties(value, false) + "\n"
+	})
+}
+
+// formatFlat flattens nested maps, joining keys with sep, and formats each of
+// the sorted keys with their string value
+func formatFlat(data map[string]interface{}, sep string, line func(key, value string) string) ([]byte, error) {
+	flat := make(map[string]string)
+	if err := Flatten(flat, "", sep, data); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	buf := new(bytes.Buffer)
+	for _, key := range keys {
+		buf.WriteString(line(key, flat[key]))
+	}
+	return buf.Bytes(), nil
+}
+
+// Flatten stores the string values of data in flat, the keys of nested maps
+// are joined with sep; the type marker is skipped
+func Flatten(flat map[string]string, prefix, sep string, data map[string]interface{}) error {
+	for key, value := range data {
+		if key == CodecTypeKey {
+			continue
+		}
+		if prefix != "" {
+			key = prefix + sep + key
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			if err := Flatten(flat, key, sep, nested); err != nil {
+				return err
+			}
+			continue
+		}
+		s, err := ValueString(value)
+		if err != nil {
+			return fmt.Errorf("key %q: %v", key, err)
+		}
+		flat[key] = s
+	}
+	return nil
+}
+
+// ValueString converts a secret value to a string; non-string values are
+// encoded as JSON
+func ValueString(value interface{}) (string, error) {
+	switch value := value.(type) {
+	case string:
+		return value, nil
+	case []byte:
+		return string(value), nil
+	case json.Number:
+		return value.String(), nil
+	case nil:
+		return "", nil
+	default:
+		b, err := json.Marshal(value)
+		return string(b), err
+	}
+}
+
+// TypedData converts JSON numbers to int64 or float64, for encoders that
+// don't know about json.Number; the type marker is removed
+func TypedData(data map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		if key == CodecTypeKey {
+			continue
+		}
+		out[key] = typedValue(value)
+	}
+	return out
+}
+
+func typedValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return i
+		}
+		if f, err := value.Float64(); err == nil {
+			return f
+		}
+		return value.String()
+	case map[string]interface{}:
+		return TypedData(value)
+	case []interface{}:
+		out := make([]interface{}, len(value))
+		for i, item := range value {
+			out[i] = typedValue(item)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+// QuoteDotenv double quotes a value for .env files if it contains special
+// characters
+func QuoteDotenv(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\r\n\"'\\$#`=") {
+		return s
+	}
+	r := strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		`$`, `\$`,
+		"`", "\\`",
+		"\n", `\n`,
+		"\r", `\r`,
+	)
+	return `"` + r.Replace(s) + `"`
+}
+
+// quoteShell single quotes a value for POSIX shells
+func quoteShell(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// EscapeProperties escapes a key or value for Java properties files
+func EscapeProperties(s string, key bool) string {
+	buf := new(bytes.Buffer)
+	for i, r := range s {
+		switch r {
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '=', ':', '#', '!', ' ':
+			if key || i == 0 {
+				buf.WriteByte('\\')
+			}
+			buf.WriteRune(r)
+		default:
+			if r < 0x20 || r > 0x7e {
+				if r1, r2 := utf16.EncodeRune(r); r1 != utf8.RuneError {
+					fmt.Fprintf(buf, `\u%04x\u%04x`, r1, r2)
+				} else {
+					fmt.Fprintf(buf, `\u%04x`, r)
+				}
+				continue
+			}
+			buf.WriteRune(r)
+		}
+	}
+	return buf.String()
+}
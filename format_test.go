@@ -0,0 +1,130 @@
+package vc
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func testFormatData() map[string]interface{} {
+	return map[string]interface{}{
+		CodecTypeKey: "json",
+		"user":       "admin",
+		"password":   "it's a \"secret\"\n$HOME",
+		"port":       json.Number("5432"),
+		"ratio":      json.Number("0.5"),
+		"enabled":    true,
+		"hosts":      []interface{}{"a", "b"},
+		"tls": map[string]interface{}{
+			"verify": false,
+		},
+	}
+}
+
+func TestFormatData(t *testing.T) {
+	tests := []struct {
+		Format string
+		Want   string
+	}{
+		{formatDotenv, `enabled=true
+hosts="[\"a\",\"b\"]"
+password="it's a \"secret\"\n\$HOME"
+port=5432
+ratio=0.5
+tls_verify=false
+user=admin
+`},
+		{formatExport, `export enabled='true'
+export hosts='["a","b"]'
+export password='it'\''s a "secret"
+$HOME'
+export port='5432'
+export ratio='0.5'
+export tls_verify='false'
+export user='admin'
+`},
+		{formatProperties, `enabled=true
+hosts=["a","b"]
+password=it's a "secret"\n$HOME
+port=5432
+ratio=0.5
+tls.verify=false
+user=admin
+`},
+		{formatTOML, `enabled = true
+hosts = ["a", "b"]
+password = "it's a \"secret\"\n$HOME"
+port = 5432
+ratio = 0.5
+user = "admin"
+
+[tls]
+  verify = false
+`},
+		{formatINI, `enabled  = true
+hosts    = ["a","b"]
+password = """it's a "secret"
+$HOME"""
+port     = 5432
+ratio    = 0.5
+user     = admin
+
+[tls]
+verify = false
+`},
+	}
+	for _, test := range tests {
+		b, err := formatData(test.Format, testFormatData())
+		if err != nil {
+			t.Fatalf("%s: %v", test.Format, err)
+		}
+		if string(b) != test.Want {
+			t.Errorf("%s: expected:\n%s\ngot:\n%s", test.Format, test.Want, b)
+		}
+	}
+
+	if _, err := formatData("xml", testFormatData()); err == nil {
+		t.Fatal("expected error for unsupported format")
+	}
+}
+
+func TestEscapeProperties(t *testing.T) {
+	tests := []struct {
+		Test string
+		Key  bool
+		Want string
+	}{
+		{"a b", true, `a\ b`},
+		{"a b", false, `a b`},
+		{" a", false, `\ a`},
+		{"a=b:c", true, `a\=b\:c`},
+		{"a=b", false, `a=b`},
+		{"caf\u00e9", false, `caf\u00e9`},
+		{"\U0001F600", false, `\ud83d\ude00`},
+		{`C:\dir`, false, `C:\\dir`},
+	}
+	for _, test := range tests {
+		if got := EscapeProperties(test.Test, test.Key); got != test.Want {
+			t.Errorf("EscapeProperties(%q, %t): expected %q, got %q", test.Test, test.Key, test.Want, got)
+		}
+	}
+}
+
+func TestValueString(t *testing.T) {
+	tests := []struct {
+		Test interface{}
+		Want string
+	}{
+		{"test", "test"},
+		{json.Number("42"), "42"},
+		{true, "true"},
+		{[]interface{}{"a", "b"}, `["a","b"]`},
+		{map[string]interface{}{"a": "b"}, `{"a":"b"}`},
+	}
+	for _, test := range tests {
+		if got, err := ValueString(test.Test); err != nil {
+			t.Fatal(err)
+		} else if got != test.Want {
+			t.Errorf("ValueString(%#v): expected %q, got %q", test.Test, test.Want, got)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package vc
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+// GenCommand generates random values into a secret
+type GenCommand struct {
+	baseCommand
+	fs    *flag.FlagSet
+	gen   generator
+	force bool
+}
+
+func (cmd *GenCommand) Help() string {
+	return "Usage: vc gen [<options>] <secret path> <key>\n\nOptions:\n" + defaults(cmd.fs)
+}
+
+func (cmd *GenCommand) Run(args []string) int {
+	if err := cmd.fs.Parse(args); err != nil {
+		return SyntaxError
+	}
+	if args = cmd.fs.Args(); len(args) != 2 {
+		return Help
+	}
+	path, key := args[0], args[1]
+
+	values, err := cmd.gen.generate(path, key)
+	if err != nil {
+		cmd.ui.Error(fmt.Sprintf("error: %v", err))
+		return SyntaxError
+	}
+
+	client, err := cmd.Client()
+	if err != nil {
+		cmd.ui.Error(err.Error())
+		return ClientError
+	}
+
+	secret, err := client.Read(path)
+	if err != nil {
+		cmd.ui.Error(err.Error())
+		return ServerError
+	}
+
+	// Other keys in the secret are kept
+	data := make(map[string]interface{})
+	if secret != nil {
+		for k, v := range secret.Data {
+			data[k] = v
+		}
+	}
+	keys := cmd.gen.keys(key)
+	for _, k := range keys {
+		if _, exists := data[k]; exists && !cmd.force {
+			cmd.ui.Error(fmt.Sprintf("error: %s: key %q already exists, use -f to overwrite", path, k))
+			return SyntaxError
+		}
+		data[k] = values[k]
+	}
+
+	if _, err = client.Write(path, data); err != nil {
+		cmd.ui.Error(err.Error())
+		return ServerError
+	}
+
+	cmd.ui.Info(fmt.Sprintf("%s: generated %s in %s", path, cmd.gen.kind, strings.Join(keys, ", ")))
+	return Success
+}
+
+func (cmd *GenCommand) Synopsis() string {
+	return "generate random passwords, tokens, keys and certificates"
+}
+
+func GenCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		cmd := &GenCommand{
+			baseCommand: baseCommand{
+				ui: ui,
+			},
+		}
+
+		cmd.fs = flag.NewFlagSet("gen", flag.ContinueOnError)
+		cmd.fs.BoolVar(&cmd.force, "f", false, "overwrite existing keys")
+		cmd.gen.flags(cmd.fs)
+		cmd.fs.Usage = func() {
+			fmt.Print(cmd.Help())
+		}
+
+		return cmd, nil
+	}
+}
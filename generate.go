@@ -0,0 +1,368 @@
+package vc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/ssh"
+)
+
+// Generator types
+const (
+	generatePassword = "password"
+	generateHex      = "hex"
+	generateBase64   = "base64"
+	generateUUID     = "uuid"
+	generateSSH      = "ssh"
+	generateTLS      = "tls"
+)
+
+// Suffixes of the extra keys written by the keypair generators
+const (
+	generatePublicSuffix = "_public"
+	generateKeySuffix    = "_key"
+)
+
+// generateClasses are the character classes for passwords
+var generateClasses = map[string]string{
+	"lower":  "abcdefghijklmnopqrstuvwxyz",
+	"upper":  "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	"digit":  "0123456789",
+	"symbol": "!#$%&()*+,-./:;<=>?@[]^_{|}~",
+}
+
+// generateClassNames in the order they are documented
+var generateClassNames = []string{"lower", "upper", "digit", "symbol"}
+
+// generator creates random secret values
+type generator struct {
+	kind      string
+	length    int
+	charset   string
+	require   string
+	exclude   string
+	algorithm string
+	bits      int
+	name      string
+	days      int
+}
+
+// flags adds the generator options to fs
+func (g *generator) flags(fs *flag.FlagSet) {
+	fs.StringVar(&g.kind, "t", generatePassword, "type: "+strings.Join([]string{generatePassword, generateHex, generateBase64, generateUUID, generateSSH, generateTLS}, ", "))
+	fs.IntVar(&g.length, "l", 32, "length of passwords in characters, or of hex and base64 tokens in bytes")
+	fs.StringVar(&g.charset, "charset", strings.Join(generateClassNames, ","), "password characters, as comma separated classes ("+strings.Join(generateClassNames, ", ")+") or literal characters")
+	fs.StringVar(&g.require, "require", "", "comma separated classes that must occur in passwords (default: all classes in the charset)")
+	fs.StringVar(&g.exclude, "exclude", "", "characters to exclude from passwords")
+	fs.StringVar(&g.algorithm, "algorithm", "", "key algorithm: ed25519 or rsa for ssh (default ed25519), ecdsa, ed25519 or rsa for tls (default ecdsa)")
+	fs.IntVar(&g.bits, "bits", 4096, "RSA key size")
+	fs.StringVar(&g.name, "name", "", "comma separated host names or IP addresses of tls certificates, the first is the common name (default: base name of the path)")
+	fs.IntVar(&g.days, "days", 365, "validity of tls certificates in days")
+}
+
+// keys returns the keys written for key
+func (g *generator) keys(key string) []string {
+	switch g.kind {
+	case generateSSH:
+		return []string{key, key + generatePublicSuffix}
+	case generateTLS:
+		return []string{key, key + generateKeySuffix}
+	default:
+		return []string{key}
+	}
+}
+
+// generate a value for key; keypairs also set the public key or private key
+// with a suffixed key name. The path is used for defaults.
+func (g *generator) generate(path, key string) (map[string]interface{}, error) {
+	switch g.kind {
+	case generatePassword:
+		s, err := g.password()
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{key: s}, nil
+
+	case generateHex, generateBase64:
+		if g.length < 1 {
+			return nil, errors.New("length must be positive")
+		}
+		b := make([]byte, g.length)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		if g.kind == generateHex {
+			return map[string]interface{}{key: hex.EncodeToString(b)}, nil
+		}
+		return map[string]interface{}{key: base64.StdEncoding.EncodeToString(b)}, nil
+
+	case generateUUID:
+		id, err := uuid.NewRandom()
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{key: id.String()}, nil
+
+	case generateSSH:
+		return g.sshKey(path, key)
+
+	case generateTLS:
+		return g.tlsCertificate(path, key)
+
+	default:
+		return nil, fmt.Errorf("unsupported type %q", g.kind)
+	}
+}
+
+// passwordClasses returns the allowed characters and the required classes,
+// without the excluded characters
+func (g *generator) passwordClasses() (charset string, required []string, err error) {
+	var (
+		parts   = strings.Split(g.charset, ",")
+		classes []string
+	)
+	for _, part := range parts {
+		if _, ok := generateClasses[part]; !ok {
+			classes = nil
+			break
+		}
+		classes = append(classes, part)
+	}
+
+	var (
+		seen  = make(map[rune]bool)
+		chars []rune
+	)
+	add := func(s string) {
+		for _, c := range s {
+			if !seen[c] && !strings.ContainsRune(g.exclude, c) {
+				seen[c] = true
+				chars = append(chars, c)
+			}
+		}
+	}
+	if classes == nil {
+		// Literal characters
+		add(g.charset)
+	} else {
+		for _, class := range classes {
+			add(generateClasses[class])
+		}
+	}
+	if len(chars) == 0 {
+		return "", nil, errors.New("no characters to choose from")
+	}
+
+	names := classes
+	if g.require != "" {
+		names = strings.Split(g.require, ",")
+	}
+	for _, name := range names {
+		class, ok := generateClasses[name]
+		if !ok {
+			return "", nil, fmt.Errorf("unknown class %q", name)
+		}
+		var allowed []rune
+		for _, c := range class {
+			if seen[c] {
+				allowed = append(allowed, c)
+			}
+		}
+		if len(allowed) == 0 {
+			if g.require == "" {
+				// Class of the charset that is excluded entirely
+				continue
+			}
+			return "", nil, fmt.Errorf("required class %q has no allowed characters", name)
+		}
+		required = append(required, string(allowed))
+	}
+	return string(chars), required, nil
+}
+
+// password generates a password with at least one character of each of the
+// required classes
+func (g *generator) password() (string, error) {
+	charset, required, err := g.passwordClasses()
+	if err != nil {
+		return "", err
+	}
+	if g.length < len(required) || g.length < 1 {
+		return "", fmt.Errorf("length %d is too short for %d required classes", g.length, len(required))
+	}
+
+	password := make([]rune, 0, g.length)
+	for _, class := range required {
+		c, err := randomRune(class)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+	for len(password) < g.length {
+		c, err := randomRune(charset)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+
+	// Fisher-Yates shuffle, so the required characters aren't up front
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := randomInt(i + 1)
+		if err != nil {
+			return "", err
+		}
+		password[i], password[j] = password[j], password[i]
+	}
+	return string(password), nil
+}
+
+// privateKey generates a private key with the configured algorithm
+func (g *generator) privateKey(algorithm string) (crypto.Signer, error) {
+	switch algorithm {
+	case "ed25519":
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	case "ecdsa":
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "rsa":
+		return rsa.GenerateKey(rand.Reader, g.bits)
+	default:
+		return nil, fmt.Errorf("unsupported %s algorithm %q", g.kind, algorithm)
+	}
+}
+
+// sshKey generates an OpenSSH private key and authorized key
+func (g *generator) sshKey(path, key string) (map[string]interface{}, error) {
+	algorithm := g.algorithm
+	if algorithm == "" {
+		algorithm = "ed25519"
+	} else if algorithm == "ecdsa" {
+		return nil, fmt.Errorf("unsupported ssh algorithm %q", algorithm)
+	}
+
+	private, err := g.privateKey(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	block, err := ssh.MarshalPrivateKey(private, g.commonName(path))
+	if err != nil {
+		return nil, err
+	}
+	public, err := ssh.NewPublicKey(private.Public())
+	if err != nil {
+		return nil, err
+	}
+	authorized := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(public))) + " " + g.commonName(path) + "\n"
+
+	return map[string]interface{}{
+		key:                        string(pem.EncodeToMemory(block)),
+		key + generatePublicSuffix: authorized,
+	}, nil
+}
+
+// tlsCertificate generates a self-signed certificate and its private key
+func (g *generator) tlsCertificate(path, key string) (map[string]interface{}, error) {
+	algorithm := g.algorithm
+	if algorithm == "" {
+		algorithm = "ecdsa"
+	}
+	if g.days < 1 {
+		return nil, errors.New("days must be positive")
+	}
+
+	private, err := g.privateKey(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: g.commonName(path)},
+		NotBefore:             now.Add(-5 * time.Minute),
+		NotAfter:              now.Add(time.Duration(g.days) * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+	}
+	for _, name := range g.names(path) {
+		if ip := net.ParseIP(name); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, name)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, private.Public(), private)
+	if err != nil {
+		return nil, err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		key:                     string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		key + generateKeySuffix: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})),
+	}, nil
+}
+
+// names returns the configured names, or the base name of the path
+func (g *generator) names(path string) (names []string) {
+	for _, name := range strings.Split(g.name, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		names = append(names, path[strings.LastIndex(path, "/")+1:])
+	}
+	return
+}
+
+func (g *generator) commonName(path string) string {
+	return g.names(path)[0]
+}
+
+// randomInt returns a uniform random integer in [0, n)
+func randomInt(n int) (int, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(i.Int64()), nil
+}
+
+// randomRune returns a uniform random character of s
+func randomRune(s string) (rune, error) {
+	chars := []rune(s)
+	i, err := randomInt(len(chars))
+	if err != nil {
+		return 0, err
+	}
+	return chars[i], nil
+}
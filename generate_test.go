@@ -0,0 +1,176 @@
+package vc
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestGeneratorPassword(t *testing.T) {
+	g := generator{
+		kind:    generatePassword,
+		length:  16,
+		charset: "lower,digit",
+		exclude: "0123456789abcdefghijklmnopqrstuvw",
+	}
+	for i := 0; i < 10; i++ {
+		s, err := g.password()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(s) != 16 {
+			t.Fatalf("expected 16 characters, got %q", s)
+		}
+		if strings.Trim(s, "xyz") != "" {
+			t.Fatalf("expected only x, y and z, got %q", s)
+		}
+	}
+
+	g = generator{length: 4, charset: "lower,upper,digit,symbol"}
+	for i := 0; i < 10; i++ {
+		s, err := g.password()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, class := range generateClassNames {
+			if !strings.ContainsAny(s, generateClasses[class]) {
+				t.Fatalf("expected %q to contain a %s character", s, class)
+			}
+		}
+	}
+
+	g = generator{length: 8, charset: "ab", require: "digit"}
+	if _, err := g.password(); err == nil {
+		t.Fatal("expected error for required class without allowed characters")
+	}
+	g = generator{length: 8, charset: "ab"}
+	if s, err := g.password(); err != nil {
+		t.Fatal(err)
+	} else if strings.Trim(s, "ab") != "" {
+		t.Fatalf("expected only a and b, got %q", s)
+	}
+	g = generator{length: 3, charset: "lower,upper,digit,symbol"}
+	if _, err := g.password(); err == nil {
+		t.Fatal("expected error for length shorter than required classes")
+	}
+}
+
+func TestGenerator(t *testing.T) {
+	g := generator{kind: generateHex, length: 16}
+	if data, err := g.generate("secret/app", "token"); err != nil {
+		t.Fatal(err)
+	} else if b, err := hex.DecodeString(data["token"].(string)); err != nil || len(b) != 16 {
+		t.Fatalf("expected 16 hex encoded bytes, got %q", data["token"])
+	}
+
+	g = generator{kind: generateBase64, length: 24}
+	if data, err := g.generate("secret/app", "token"); err != nil {
+		t.Fatal(err)
+	} else if b, err := base64.StdEncoding.DecodeString(data["token"].(string)); err != nil || len(b) != 24 {
+		t.Fatalf("expected 24 base64 encoded bytes, got %q", data["token"])
+	}
+
+	g = generator{kind: generateUUID}
+	if data, err := g.generate("secret/app", "id"); err != nil {
+		t.Fatal(err)
+	} else if _, err = uuid.Parse(data["id"].(string)); err != nil {
+		t.Fatal(err)
+	}
+
+	g = generator{kind: generateSSH}
+	data, err := g.generate("secret/deploy", "ssh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.ParsePrivateKey([]byte(data["ssh"].(string)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, comment, _, _, err := ssh.ParseAuthorizedKey([]byte(data["ssh_public"].(string)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if public.Type() != ssh.KeyAlgoED25519 || string(public.Marshal()) != string(signer.PublicKey().Marshal()) || comment != "deploy" {
+		t.Fatalf("unexpected public key %q", data["ssh_public"])
+	}
+
+	g = generator{kind: generateTLS, name: "example.org,127.0.0.1", days: 30}
+	if data, err = g.generate("secret/tls", "cert"); err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode([]byte(data["cert"].(string)))
+	if block == nil {
+		t.Fatalf("expected PEM certificate, got %q", data["cert"])
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.Subject.CommonName != "example.org" || len(cert.DNSNames) != 1 || len(cert.IPAddresses) != 1 {
+		t.Fatalf("unexpected certificate %+v", cert)
+	}
+	if block, _ = pem.Decode([]byte(data["cert_key"].(string))); block == nil || block.Type != "PRIVATE KEY" {
+		t.Fatalf("expected PEM private key, got %q", data["cert_key"])
+	}
+}
+
+func TestGenCommand(t *testing.T) {
+	_, client := testMemoryClient()
+	testWrite(t, client, "kv/app/db", map[string]interface{}{"user": "app", "password": "old"})
+
+	for _, test := range []testCommand{
+		testCommand{
+			Factory: GenCommandFactory,
+			Args:    []string{"--help"},
+			Code:    Success,
+		},
+		testCommand{
+			Factory: GenCommandFactory,
+			Args:    []string{"-t", "nonsense", "kv/app/db", "password"},
+			Code:    SyntaxError,
+			Client:  client,
+		},
+		testCommand{
+			Factory: GenCommandFactory,
+			Args:    []string{"kv/app/db", "password"},
+			Code:    SyntaxError,
+			Client:  client,
+		},
+		testCommand{
+			Factory: GenCommandFactory,
+			Args:    []string{"-f", "-l", "20", "kv/app/db", "password"},
+			Code:    Success,
+			Client:  client,
+		},
+		testCommand{
+			Factory: GenCommandFactory,
+			Args:    []string{"-t", "uuid", "secret/app/new", "id"},
+			Code:    Success,
+			Client:  client,
+		},
+	} {
+		testCommandRun(t, test)
+	}
+
+	secret, err := client.Read("kv/app/db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.Data["user"] != "app" {
+		t.Fatalf("expected other keys to be kept, got %+v", secret.Data)
+	}
+	if password, _ := secret.Data["password"].(string); len(password) != 20 {
+		t.Fatalf("expected a new password of 20 characters, got %q", password)
+	}
+	if secret, err = client.Read("secret/app/new"); err != nil {
+		t.Fatal(err)
+	} else if secret == nil || secret.Data["id"] == nil {
+		t.Fatalf("expected new secret with id, got %+v", secret)
+	}
+}
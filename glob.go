@@ -0,0 +1,306 @@
+package vc
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// globMeta are the characters that make a pattern a glob
+const globMeta = "*?[{"
+
+// isGlob checks if pattern is a glob; patterns that do not parse as glob, such
+// as "a[1", are literal paths
+func (c *Client) isGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, globMeta) && validGlob(pattern)
+}
+
+// validGlob checks if all segments of pattern compile
+func validGlob(pattern string) bool {
+	for _, expanded := range expandBraces(pattern) {
+		for _, segment := range strings.Split(expanded, "/") {
+			if segment == "**" || !strings.ContainsAny(segment, "*?[") {
+				continue
+			}
+			if _, err := globSegment(segment); err != nil {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Glob is a shortcut to list key/value secrets and mounts by glob pattern.
+// Supported are the wildcards "*" and "?", character classes such as "[abc]"
+// and "[a-z]" (negated by a leading "!" or "^"), alternatives such as
+// "{prod,staging}" and "**", which matches any number of directories. Special
+// characters may be escaped with a backslash. A trailing "/" only matches
+// directories.
+func (c *Client) Glob(pattern string) ([]os.FileInfo, error) {
+	Debugf("glob: %q", pattern)
+
+	// Fast path, no globbing required
+	if !c.isGlob(pattern) {
+		info, err := c.Stat(pattern)
+		return []os.FileInfo{info}, err
+	}
+
+	// Secrets may have glob characters in their name, an existing literal
+	// path takes precedence
+	if info, err := c.Stat(pattern); err == nil {
+		return []os.FileInfo{info}, nil
+	}
+
+	var (
+		infos   []os.FileInfo
+		seen    = make(map[string]bool)
+		dirOnly = strings.HasSuffix(pattern, "/")
+	)
+	for _, expanded := range expandBraces(c.abspath(pattern)) {
+		Debugf("glob abs: %q", expanded)
+		matches, err := c.glob("/", strings.Split(strings.Trim(expanded, "/"), "/"))
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range matches {
+			if dirOnly && !info.IsDir() {
+				continue
+			}
+			key := info.Name()
+			if info.IsDir() {
+				key += "/"
+			}
+			if !seen[key] {
+				seen[key] = true
+				infos = append(infos, info)
+			}
+		}
+	}
+
+	sort.SliceStable(infos, func(i, j int) bool {
+		return infos[i].Name() < infos[j].Name()
+	})
+	return infos, nil
+}
+
+// glob matches the path segments against the items in dir
+func (c *Client) glob(dir string, segments []string) (infos []os.FileInfo, err error) {
+	if len(segments) == 0 {
+		return
+	}
+	segment, rest := segments[0], segments[1:]
+
+	// Literal segment, no need to list the directory
+	if !strings.ContainsAny(segment, "*?[") {
+		name := path.Join(dir, globUnescape(segment))
+		if len(rest) > 0 {
+			return c.glob(name, rest)
+		}
+		var info os.FileInfo
+		if info, err = c.Stat(name); os.IsNotExist(err) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		return []os.FileInfo{info}, nil
+	}
+
+	var filter *regexp.Regexp
+	if segment != "**" {
+		if filter, err = globSegment(segment); err != nil {
+			return
+		}
+	}
+
+	var items []os.FileInfo
+	if items, err = c.ReadDir(dir); err != nil {
+		if isPermissionDenied(err) {
+			Debugf("glob: %s: %v", dir, err)
+			return nil, nil
+		}
+		return
+	}
+
+	if segment == "**" {
+		// Matches zero directories
+		if len(rest) > 0 {
+			if infos, err = c.glob(dir, rest); err != nil {
+				return
+			}
+		}
+		// Matches one or more directories
+		for _, item := range items {
+			if len(rest) == 0 {
+				infos = append(infos, item)
+			}
+			if item.IsDir() {
+				var more []os.FileInfo
+				if more, err = c.glob(item.Name(), segments); err != nil {
+					return
+				}
+				infos = append(infos, more...)
+			}
+		}
+		return
+	}
+
+	for _, item := range items {
+		Debugf("filter: %q =~ %s", item.Name(), filter)
+		if !filter.MatchString(path.Base(item.Name())) {
+			continue
+		}
+		if len(rest) == 0 {
+			infos = append(infos, item)
+		} else if item.IsDir() {
+			var more []os.FileInfo
+			if more, err = c.glob(item.Name(), rest); err != nil {
+				return
+			}
+			infos = append(infos, more...)
+		}
+	}
+	return
+}
+
+// expandBraces expands alternatives in braces, "a{b,c}" becomes "ab" and "ac"
+func expandBraces(pattern string) []string {
+	var (
+		start  = -1
+		depth  int
+		commas []int
+	)
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case '{':
+			if depth == 0 {
+				start, commas = i, nil
+			}
+			depth++
+		case ',':
+			if depth == 1 {
+				commas = append(commas, i)
+			}
+		case '}':
+			if depth == 0 {
+				continue
+			}
+			if depth--; depth > 0 {
+				continue
+			}
+			if len(commas) == 0 {
+				// Braces without alternatives are literal
+				continue
+			}
+
+			var (
+				expanded []string
+				bounds   = append(append([]int{start}, commas...), i)
+			)
+			for j := 1; j < len(bounds); j++ {
+				alternative := pattern[bounds[j-1]+1 : bounds[j]]
+				expanded = append(expanded, expandBraces(pattern[:start]+alternative+pattern[i+1:])...)
+			}
+			return expanded
+		}
+	}
+	return []string{pattern}
+}
+
+// globSegment compiles a glob for a single path segment to a regular expression
+func globSegment(segment string) (*regexp.Regexp, error) {
+	var (
+		expr  = []string{"^"}
+		runes = []rune(segment)
+	)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; r {
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+			}
+			expr = append(expr, regexp.QuoteMeta(string(runes[i])))
+		case '*':
+			expr = append(expr, "[^/]*")
+		case '?':
+			expr = append(expr, "[^/]")
+		case '[':
+			class, n, err := globClass(runes[i:])
+			if err != nil {
+				return nil, fmt.Errorf("vc: glob %q: %v", segment, err)
+			}
+			expr = append(expr, class)
+			i += n - 1
+		default:
+			expr = append(expr, regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr = append(expr, "$")
+	return regexp.Compile(strings.Join(expr, ""))
+}
+
+// globClass compiles a character class starting at runes[0] == '[', returns
+// the expression and the number of runes consumed
+func globClass(runes []rune) (string, int, error) {
+	var (
+		expr = []string{"["}
+		i    = 1
+	)
+	if i < len(runes) && (runes[i] == '!' || runes[i] == '^') {
+		expr = append(expr, "^")
+		i++
+	}
+	for start := i; i < len(runes); i++ {
+		switch r := runes[i]; {
+		case r == ']' && i > start:
+			expr = append(expr, "]")
+			return strings.Join(expr, ""), i + 1, nil
+		case r == '\\' && i+1 < len(runes):
+			i++
+			expr = append(expr, `\`+string(runes[i]))
+		case r == '-':
+			expr = append(expr, "-")
+		case r == '/':
+			return "", 0, fmt.Errorf("invalid character class")
+		case strings.ContainsRune(`\[]^`, r):
+			expr = append(expr, `\`+string(r))
+		default:
+			expr = append(expr, string(r))
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated character class")
+}
+
+// globUnescape removes the escaping backslashes from a literal segment
+func globUnescape(segment string) string {
+	if !strings.Contains(segment, `\`) {
+		return segment
+	}
+	var (
+		out   []rune
+		runes = []rune(segment)
+	)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == '\\' && i+1 < len(runes) {
+			i++
+		}
+		out = append(out, runes[i])
+	}
+	return string(out)
+}
+
+// globPath compiles a glob for a whole path to a regular expression, wildcards
+// also match the path separator
+func globPath(pattern string) (*regexp.Regexp, error) {
+	re, err := globSegment(pattern)
+	if err != nil {
+		return nil, err
+	}
+	// Character classes can not contain a slash, so these only come from wildcards
+	expr := strings.Replace(re.String(), "[^/]", ".", -1)
+	return regexp.Compile(expr)
+}
@@ -0,0 +1,127 @@
+package vc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExpandBraces(t *testing.T) {
+	tests := []struct {
+		Test string
+		Want []string
+	}{
+		{"foo", []string{"foo"}},
+		{"a{b,c}d", []string{"abd", "acd"}},
+		{"{a,b}/{c,d}", []string{"a/c", "a/d", "b/c", "b/d"}},
+		{"a{b,{c,d}}", []string{"ab", "ac", "ad"}},
+		{"a{b}", []string{"a{b}"}},
+		{`a\{b,c}`, []string{`a\{b,c}`}},
+		{"a{,b}", []string{"a", "ab"}},
+	}
+	for _, test := range tests {
+		if got := expandBraces(test.Test); !reflect.DeepEqual(got, test.Want) {
+			t.Errorf("expandBraces(%q): expected %q, got %q", test.Test, test.Want, got)
+		}
+	}
+}
+
+func TestGlobSegment(t *testing.T) {
+	tests := []struct {
+		Test  string
+		Match []string
+		Miss  []string
+	}{
+		{"*", []string{"", "foo"}, []string{"foo/bar"}},
+		{"f?o", []string{"foo", "fao"}, []string{"fo", "fooo"}},
+		{"[ab]*", []string{"a", "bar"}, []string{"car"}},
+		{"[a-c]x", []string{"ax", "cx"}, []string{"dx"}},
+		{"[!a]*", []string{"bar"}, []string{"abc"}},
+		{"[^a]*", []string{"bar"}, []string{"abc"}},
+		{`\*`, []string{"*"}, []string{"foo"}},
+		{"a.b", []string{"a.b"}, []string{"axb"}},
+		{`[\]]`, []string{"]"}, []string{"a"}},
+	}
+	for _, test := range tests {
+		re, err := globSegment(test.Test)
+		if err != nil {
+			t.Fatalf("globSegment(%q): %v", test.Test, err)
+		}
+		for _, s := range test.Match {
+			if !re.MatchString(s) {
+				t.Errorf("globSegment(%q): expected match on %q", test.Test, s)
+			}
+		}
+		for _, s := range test.Miss {
+			if re.MatchString(s) {
+				t.Errorf("globSegment(%q): unexpected match on %q", test.Test, s)
+			}
+		}
+	}
+
+	for _, test := range []string{"[abc", "[]"} {
+		if _, err := globSegment(test); err == nil {
+			t.Errorf("globSegment(%q): expected error", test)
+		}
+	}
+}
+
+func TestClientGlob(t *testing.T) {
+	_, c := testMemoryClient()
+	for _, path := range []string{
+		"secret/app/prod/db",
+		"secret/app/prod/api",
+		"secret/app/staging/db",
+		"secret/app/test/db",
+		"secret/app/db",
+		"secret/b*r",
+		"secret/app/a[1",
+		"secret/app/x[1]",
+		"kv/app/prod/db",
+	} {
+		testWrite(t, c, path, map[string]interface{}{"key": "test"})
+	}
+
+	tests := []struct {
+		Test string
+		Want []string
+	}{
+		{"secret/app/*/db", []string{"/secret/app/prod/db", "/secret/app/staging/db", "/secret/app/test/db"}},
+		{"secret/app/{prod,staging}/db", []string{"/secret/app/prod/db", "/secret/app/staging/db"}},
+		{"secret/app/[ps]*/db", []string{"/secret/app/prod/db", "/secret/app/staging/db"}},
+		{"secret/**/db", []string{"/secret/app/db", "/secret/app/prod/db", "/secret/app/staging/db", "/secret/app/test/db"}},
+		{"*/app/prod/db", []string{"/kv/app/prod/db", "/secret/app/prod/db"}},
+		{"secret/app/*/", []string{"/secret/app/prod/", "/secret/app/staging/", "/secret/app/test/"}},
+		{`secret/b\*r`, []string{"/secret/b*r"}},
+		{"secret/app/prod/*", []string{"/secret/app/prod/api", "/secret/app/prod/db"}},
+		{"secret/nope/*", nil},
+		{"secret/app/a[1", []string{"/secret/app/a[1"}},
+		{"secret/app/x[1]", []string{"/secret/app/x[1]"}},
+	}
+	for _, test := range tests {
+		infos, err := c.Glob(test.Test)
+		if err != nil {
+			t.Fatalf("Glob(%q): %v", test.Test, err)
+		}
+		var got []string
+		for _, info := range infos {
+			name := info.Name()
+			if info.IsDir() {
+				name += "/"
+			}
+			got = append(got, name)
+		}
+		if !reflect.DeepEqual(got, test.Want) {
+			t.Errorf("Glob(%q): expected %q, got %q", test.Test, test.Want, got)
+		}
+	}
+
+	// Paths that are not valid globs are literal
+	for _, path := range []string{"secret/app/a[1", "secret/app/x[1]"} {
+		testCommandRun(t, testCommand{
+			Factory: CatCommandFactory,
+			Args:    []string{path},
+			Code:    Success,
+			Client:  c,
+		})
+	}
+}
@@ -0,0 +1,274 @@
+package vc
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+// NoMatch is the return code of grep if nothing matched; like grep(1), errors
+// return a code above 1
+const NoMatch = 1
+
+// GrepCommand searches secrets by key name and value
+type GrepCommand struct {
+	baseCommand
+	fs         *flag.FlagSet
+	recurse    bool
+	keysOnly   bool
+	showValues bool
+	ignoreCase bool
+}
+
+// grepMatch is a matching key, or a matching line in a value or payload
+type grepMatch struct {
+	key  string
+	line string
+}
+
+// grepJob is a secret to search, with its pending result
+type grepJob struct {
+	name   string
+	result grepResult
+}
+
+// grepResult is the pending result of searching a secret
+type grepResult chan grepOutcome
+
+type grepOutcome struct {
+	matches []grepMatch
+	err     error
+}
+
+func (cmd *GrepCommand) Help() string {
+	return "Usage: vc grep [<options>] <regex> <secret path> [... <secret path>]\n\n" +
+		"The exit status is 0 if a secret matched, 1 if nothing matched and above 1\n" +
+		"on errors.\n\nOptions:\n" + defaults(cmd.fs)
+}
+
+func (cmd *GrepCommand) Run(args []string) int {
+	// Help and syntax errors would return 1, which means nothing matched
+	if err := cmd.fs.Parse(args); err != nil {
+		return UsageError
+	}
+	if args = cmd.fs.Args(); len(args) < 2 {
+		cmd.ui.Error(cmd.Help())
+		return UsageError
+	}
+
+	expr := args[0]
+	if cmd.ignoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		cmd.ui.Error(fmt.Sprintf("error: %v", err))
+		return UsageError
+	}
+
+	client, err := cmd.Client()
+	if err != nil {
+		cmd.ui.Error(err.Error())
+		return ClientError
+	}
+
+	roots, err := cmd.globs(client, args[1:])
+	if err != nil {
+		cmd.ui.Error(fmt.Sprintf("error: %v", err))
+		return UsageError
+	}
+
+	var (
+		ret  = NoMatch
+		jobs = make(chan grepJob)
+		// Pending searches in walk order, bounds the searches ahead of output
+		queue = make(chan grepJob, 2*walkParallel)
+	)
+	for i := 0; i < walkParallel; i++ {
+		go cmd.worker(client, re, jobs)
+	}
+	go func() {
+		defer close(jobs)
+		defer close(queue)
+		search := func(name string) {
+			job := grepJob{name: name, result: make(grepResult, 1)}
+			jobs <- job
+			queue <- job
+		}
+		fail := func(name string, err error) {
+			job := grepJob{name: name, result: make(grepResult, 1)}
+			job.result <- grepOutcome{err: err}
+			queue <- job
+		}
+		for _, root := range roots {
+			if !cmd.recurse {
+				search(client.abspath(root))
+				continue
+			}
+			err := client.Walk(root, func(name string, err error) error {
+				if err != nil {
+					fail(name, err)
+					return nil
+				}
+				if !isChunkPath(name) {
+					search(name)
+				}
+				return nil
+			})
+			if err != nil {
+				fail(root, err)
+				return
+			}
+		}
+	}()
+
+	for job := range queue {
+		outcome := <-job.result
+		if outcome.err != nil {
+			cmd.ui.Error(fmt.Sprintf("%s: %v", job.name, outcome.err))
+			ret = ServerError
+			continue
+		}
+		for _, match := range outcome.matches {
+			if cmd.showValues && match.line != "" {
+				cmd.ui.Output(fmt.Sprintf("%s:%s: %s", job.name, match.key, match.line))
+			} else {
+				cmd.ui.Output(fmt.Sprintf("%s:%s", job.name, match.key))
+			}
+		}
+		if len(outcome.matches) > 0 && ret == NoMatch {
+			ret = Success
+		}
+	}
+	return ret
+}
+
+// worker searches the secrets sent on jobs until it is closed
+func (cmd *GrepCommand) worker(client *Client, re *regexp.Regexp, jobs <-chan grepJob) {
+	for job := range jobs {
+		job.result <- cmd.search(client, job.name, re)
+	}
+}
+
+// search a secret
+func (cmd *GrepCommand) search(client *Client, name string, re *regexp.Regexp) grepOutcome {
+	secret, err := client.Read(name)
+	if err != nil {
+		return grepOutcome{err: err}
+	} else if secret == nil {
+		if !cmd.recurse {
+			err = errors.New("secret not found")
+		}
+		return grepOutcome{err: err}
+	}
+
+	payload, err := grepPayload(client, name, secret.Data)
+	if err != nil {
+		return grepOutcome{err: err}
+	}
+	return grepOutcome{matches: grepData(re, secret.Data, payload, cmd.keysOnly)}
+}
+
+// grepPayload decodes typed secrets with the codec of their type
+func grepPayload(client *Client, name string, data map[string]interface{}) ([]byte, error) {
+	kind, ok := data[CodecTypeKey].(string)
+	if !ok {
+		return nil, nil
+	}
+	if kind == "file" {
+		return client.readFile(name, data)
+	}
+
+	codec, err := CodecFor(kind)
+	if err != nil {
+		return nil, err
+	}
+	plain := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		if key != CodecTypeKey {
+			plain[key] = value
+		}
+	}
+	return codec.Marshal(name, plain)
+}
+
+// grepData matches the keys and values of data; the values of typed secrets
+// are matched in their decoded payload, by line number prefixed with the type
+func grepData(re *regexp.Regexp, data map[string]interface{}, payload []byte, keysOnly bool) (matches []grepMatch) {
+	kind, typed := data[CodecTypeKey].(string)
+
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		if key != CodecTypeKey {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if kind == "file" {
+			// The keys of files are metadata
+			break
+		}
+		value, err := ValueString(data[key])
+		if err != nil {
+			continue
+		}
+		if re.MatchString(key) {
+			matches = append(matches, grepMatch{key: key, line: strings.SplitN(value, "\n", 2)[0]})
+			continue
+		}
+		if keysOnly || typed {
+			continue
+		}
+		for _, line := range strings.Split(value, "\n") {
+			if re.MatchString(line) {
+				matches = append(matches, grepMatch{key: key, line: line})
+				break
+			}
+		}
+	}
+
+	if typed && !keysOnly {
+		scanner := bufio.NewScanner(bytes.NewReader(payload))
+		scanner.Buffer(nil, len(payload)+1)
+		for n := 1; scanner.Scan(); n++ {
+			if line := scanner.Text(); re.MatchString(line) {
+				matches = append(matches, grepMatch{key: fmt.Sprintf("%s:%d", kind, n), line: line})
+			}
+		}
+	}
+	return
+}
+
+func (cmd *GrepCommand) Synopsis() string {
+	return "search secrets by key name and value"
+}
+
+func GrepCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		cmd := &GrepCommand{
+			baseCommand: baseCommand{
+				ui: ui,
+			},
+		}
+
+		cmd.fs = flag.NewFlagSet("grep", flag.ContinueOnError)
+		cmd.fs.BoolVar(&cmd.recurse, "R", false, "recursively search secrets below the path")
+		cmd.fs.BoolVar(&cmd.keysOnly, "k", false, "only match key names")
+		cmd.fs.BoolVar(&cmd.showValues, "v", false, "show matching values, instead of masking them")
+		cmd.fs.BoolVar(&cmd.ignoreCase, "i", false, "ignore case")
+		cmd.fs.Usage = func() {
+			fmt.Print(cmd.Help())
+		}
+
+		return cmd, nil
+	}
+}
@@ -0,0 +1,129 @@
+package vc
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestGrepData(t *testing.T) {
+	data := map[string]interface{}{
+		"aws_secret_access_key": "secret",
+		"url":                   "https://old.example.org/",
+		"hosts":                 []interface{}{"a.example.org", "old.example.org"},
+		"notes":                 "first line\nold.example.org\n",
+	}
+	want := []grepMatch{
+		{key: "hosts", line: `["a.example.org","old.example.org"]`},
+		{key: "notes", line: "old.example.org"},
+		{key: "url", line: "https://old.example.org/"},
+	}
+	if got := grepData(regexp.MustCompile(`old\.example`), data, nil, false); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+	want = []grepMatch{{key: "aws_secret_access_key", line: "secret"}}
+	if got := grepData(regexp.MustCompile(`^aws_`), data, nil, true); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+	if got := grepData(regexp.MustCompile(`example`), data, nil, true); len(got) != 0 {
+		t.Fatalf("expected no key matches, got %+v", got)
+	}
+
+	// Typed secrets are matched in their payload
+	file := map[string]interface{}{
+		CodecTypeKey: "file",
+		"contents":   encodeContents([]byte("listen = 80\nhost = old.example.org\n")),
+	}
+	want = []grepMatch{{key: "file:2", line: "host = old.example.org"}}
+	if got := grepData(regexp.MustCompile(`old\.example`), file, []byte("listen = 80\nhost = old.example.org\n"), false); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestGrepCommand(t *testing.T) {
+	_, client := testMemoryClient()
+	testWrite(t, client, "secret/app/db", map[string]interface{}{"host": "old.example.org"})
+	testWrite(t, client, "secret/app/api", map[string]interface{}{"aws_secret_access_key": "secret"})
+	if err := client.writeFile("secret/app/conf", []byte("host = old.example.org\n"), nil, encodingGzip, 8); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []testCommand{
+		testCommand{
+			Factory: GrepCommandFactory,
+			Args:    []string{"--help"},
+			Code:    Success,
+		},
+		testCommand{
+			Factory: GrepCommandFactory,
+			Args:    []string{"old.example"},
+			Code:    UsageError,
+		},
+		testCommand{
+			Factory: GrepCommandFactory,
+			Args:    []string{"(", "secret/app/db"},
+			Code:    UsageError,
+			Client:  client,
+		},
+		testCommand{
+			Factory: GrepCommandFactory,
+			Args:    []string{"old.example", "secret/app/db"},
+			Code:    Success,
+			Client:  client,
+		},
+		testCommand{
+			Factory: GrepCommandFactory,
+			Args:    []string{"old.example", "secret/app/api"},
+			Code:    NoMatch,
+			Client:  client,
+		},
+		testCommand{
+			Factory: GrepCommandFactory,
+			Args:    []string{"old.example", "secret/app/missing"},
+			Code:    ServerError,
+			Client:  client,
+		},
+		testCommand{
+			Factory: GrepCommandFactory,
+			Args:    []string{"-R", "-k", "-i", "AWS_SECRET", "secret/"},
+			Code:    Success,
+			Client:  client,
+		},
+	} {
+		testCommandRun(t, test)
+	}
+
+	cmd := &GrepCommand{recurse: true}
+	outcome := cmd.search(client, "/secret/app/conf", regexp.MustCompile(`old\.example`))
+	if outcome.err != nil {
+		t.Fatal(outcome.err)
+	}
+	if want := []grepMatch{{key: "file:1", line: "host = old.example.org"}}; !reflect.DeepEqual(outcome.matches, want) {
+		t.Fatalf("expected %+v, got %+v", want, outcome.matches)
+	}
+}
+
+func TestGrepCommandOrder(t *testing.T) {
+	_, client := testMemoryClient()
+	var want []string
+	for i := 0; i < 4*walkParallel; i++ {
+		name := fmt.Sprintf("secret/many/%03d", i)
+		testWrite(t, client, name, map[string]interface{}{"host": "old.example.org"})
+		want = append(want, "/"+name+":host")
+	}
+
+	ui := cli.NewMockUi()
+	command, _ := GrepCommandFactory(ui)()
+	cmd := command.(*GrepCommand)
+	cmd.setClient(client)
+	if code := cmd.Run([]string{"-R", "old", "secret/many"}); code != Success {
+		t.Fatalf("expected return code %d, got %d: %s", Success, code, ui.ErrorWriter)
+	}
+	if got := strings.Split(strings.TrimRight(ui.OutputWriter.String(), "\n"), "\n"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
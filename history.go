@@ -0,0 +1,100 @@
+package vc
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mitchellh/cli"
+)
+
+// HistoryCommand lists the versions of a secret
+type HistoryCommand struct {
+	baseCommand
+	fs *flag.FlagSet
+}
+
+func (cmd *HistoryCommand) Help() string {
+	return "Usage: vc history <secret path>\n\nOptions:\n" + defaults(cmd.fs)
+}
+
+func (cmd *HistoryCommand) Run(args []string) int {
+	if err := cmd.fs.Parse(args); err != nil {
+		return SyntaxError
+	}
+	if args = cmd.fs.Args(); len(args) != 1 {
+		return Help
+	}
+
+	client, err := cmd.Client()
+	if err != nil {
+		cmd.ui.Error(err.Error())
+		return ClientError
+	}
+
+	meta, err := client.Metadata(args[0])
+	if err == ErrNotVersioned {
+		cmd.ui.Error(fmt.Sprintf("error: %s: %v", args[0], err))
+		return SyntaxError
+	} else if err != nil {
+		cmd.ui.Error(err.Error())
+		return ServerError
+	}
+	if meta == nil {
+		cmd.ui.Error(fmt.Sprintf("error: %s: secret not found", args[0]))
+		return SyntaxError
+	}
+
+	buf := new(bytes.Buffer)
+	w := tabwriter.NewWriter(buf, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tCREATED\tDELETED\tDESTROYED")
+	for _, version := range meta.Versions {
+		current := ""
+		if version.Version == meta.CurrentVersion {
+			current = " *"
+		}
+		destroyed := "no"
+		if version.Destroyed {
+			destroyed = "yes"
+		}
+		fmt.Fprintf(w, "%d%s\t%s\t%s\t%s\n", version.Version, current,
+			historyTime(version.CreatedTime),
+			historyTime(version.DeletionTime),
+			destroyed)
+	}
+	w.Flush()
+
+	cmd.ui.Output(strings.TrimRight(buf.String(), "\n"))
+	return Success
+}
+
+func historyTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(time.RFC3339)
+}
+
+func (cmd *HistoryCommand) Synopsis() string {
+	return "show the version history of a secret"
+}
+
+func HistoryCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		cmd := &HistoryCommand{
+			baseCommand: baseCommand{
+				ui: ui,
+			},
+		}
+
+		cmd.fs = flag.NewFlagSet("history", flag.ContinueOnError)
+		cmd.fs.Usage = func() {
+			fmt.Print(cmd.Help())
+		}
+
+		return cmd, nil
+	}
+}
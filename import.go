@@ -0,0 +1,193 @@
+package vc
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/mitchellh/cli"
+)
+
+// Conflict policies for import
+const (
+	conflictPrompt    = "prompt"
+	conflictSkip      = "skip"
+	conflictOverwrite = "overwrite"
+	conflictFail      = "fail"
+)
+
+// ImportCommand restores a tree of secrets from an archive
+type ImportCommand struct {
+	baseCommand
+	fs       *flag.FlagSet
+	format   string
+	key      string
+	conflict string
+	force    bool
+	dryRun   bool
+}
+
+func (cmd *ImportCommand) Help() string {
+	return "Usage: vc import [<options>] <archive> [<secret path>]\n\nOptions:\n" + defaults(cmd.fs)
+}
+
+func (cmd *ImportCommand) Run(args []string) int {
+	if err := cmd.fs.Parse(args); err != nil {
+		return SyntaxError
+	}
+	if args = cmd.fs.Args(); len(args) < 1 || len(args) > 2 {
+		return Help
+	}
+
+	if cmd.force {
+		cmd.conflict = conflictOverwrite
+	}
+	switch cmd.conflict {
+	case conflictPrompt, conflictSkip, conflictOverwrite, conflictFail:
+	default:
+		cmd.ui.Error(fmt.Sprintf("error: unsupported conflict policy %q", cmd.conflict))
+		return SyntaxError
+	}
+
+	var (
+		p   []byte
+		err error
+	)
+	if name := args[0]; name == "-" {
+		p, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		p, err = ioutil.ReadFile(name)
+	}
+	if err != nil {
+		cmd.ui.Error(fmt.Sprintf("error: %v", err))
+		return SystemError
+	}
+
+	// Encrypted archives are decrypted transparently
+	if isSealed(p) {
+		if p, err = cmd.unseal(p, cmd.key); err != nil {
+			cmd.ui.Error(fmt.Sprintf("error: %s: %v", args[0], err))
+			return CodecError
+		}
+	}
+
+	a, err := readArchive(bytes.NewReader(p), cmd.format)
+	if err != nil {
+		cmd.ui.Error(fmt.Sprintf("error: %s: %v", args[0], err))
+		return CodecError
+	}
+
+	client, err := cmd.Client()
+	if err != nil {
+		cmd.ui.Error(err.Error())
+		return ClientError
+	}
+
+	// Secrets are restored to their original root, unless remapped
+	root := a.Root
+	if len(args) == 2 {
+		root = client.abspath(args[1])
+	}
+	if root == "" {
+		cmd.ui.Error("error: archive has no root, supply a secret path")
+		return SyntaxError
+	}
+
+	var summary treeSummary
+	for _, rel := range a.paths() {
+		name := path.Join(root, rel)
+
+		ok, err := cmd.allowed(client, name)
+		if err != nil {
+			cmd.ui.Error(fmt.Sprintf("%s: %v", name, err))
+			if cmd.conflict == conflictFail {
+				return ServerError
+			}
+			summary.failed++
+			continue
+		} else if !ok {
+			summary.skipped++
+			continue
+		}
+
+		if cmd.dryRun {
+			cmd.ui.Output("would import " + name)
+			summary.done++
+			continue
+		}
+		if _, err = client.Write(name, a.Secrets[rel]); err != nil {
+			cmd.ui.Error(fmt.Sprintf("%s: %v", name, err))
+			summary.failed++
+			continue
+		}
+		Debugf("import: %s", name)
+		summary.done++
+	}
+
+	cmd.ui.Info("imported: " + summary.String())
+	if summary.failed > 0 {
+		return ServerError
+	}
+	return Success
+}
+
+// allowed checks if the secret at name may be written according to the
+// conflict policy
+func (cmd *ImportCommand) allowed(client *Client, name string) (bool, error) {
+	switch cmd.conflict {
+	case conflictOverwrite:
+		return true, nil
+	case conflictPrompt:
+		if cmd.dryRun {
+			break
+		}
+		return cmd.overwrite(client, name, false)
+	}
+
+	secret, err := client.Read(name)
+	if err != nil {
+		return false, err
+	}
+	if secret == nil {
+		return true, nil
+	}
+	switch cmd.conflict {
+	case conflictSkip:
+		Debugf("import: skipping existing %s", name)
+		return false, nil
+	case conflictFail:
+		return false, fmt.Errorf("secret already exists")
+	}
+	// Dry run with prompt policy, report instead of asking
+	cmd.ui.Output("would prompt to overwrite " + name)
+	return false, nil
+}
+
+func (cmd *ImportCommand) Synopsis() string {
+	return "import secrets from an archive"
+}
+
+func ImportCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		cmd := &ImportCommand{
+			baseCommand: baseCommand{
+				ui: ui,
+			},
+		}
+
+		cmd.fs = flag.NewFlagSet("import", flag.ContinueOnError)
+		cmd.fs.StringVar(&cmd.format, "format", "", "archive format: tar, json or yaml (default: detected)")
+		cmd.fs.StringVar(&cmd.conflict, "conflict", conflictPrompt, "policy for existing secrets: prompt, skip, overwrite or fail")
+		cmd.fs.BoolVar(&cmd.force, "f", false, "force overwrite, same as -conflict overwrite")
+		cmd.fs.BoolVar(&cmd.dryRun, "n", false, "dry run, show what would be imported")
+		cmd.fs.StringVar(&cmd.key, "k", "", "private key file (for archives encrypted to public keys)")
+		cmd.fs.Usage = func() {
+			fmt.Print(cmd.Help())
+		}
+
+		return cmd, nil
+	}
+}
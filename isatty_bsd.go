@@ -15,3 +15,14 @@ func IsTerminal(fd uintptr) bool {
 	_, _, err := syscall.Syscall6(syscall.SYS_IOCTL, fd, ioctlReadTermios, uintptr(unsafe.Pointer(&termios)), 0, 0, 0)
 	return err == 0
 }
+
+// terminalWidth returns the number of columns of the terminal, or 0 if the
+// file descriptor is not a terminal.
+func terminalWidth(fd uintptr) int {
+	var size struct{ rows, cols, xpixel, ypixel uint16 }
+	_, _, err := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&size)))
+	if err != 0 {
+		return 0
+	}
+	return int(size.cols)
+}
@@ -9,3 +9,7 @@ func TestIsTerminal(_ *testing.T) {
 	_ = IsTerminal(os.Stdout.Fd())
 	_ = IsTerminal(os.Stderr.Fd())
 }
+
+func TestTerminalWidth(_ *testing.T) {
+	_ = terminalWidth(os.Stdout.Fd())
+}
@@ -3,9 +3,16 @@ package vc
 import (
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
 
 	"github.com/mitchellh/cli"
 )
@@ -17,6 +24,9 @@ type ListCommand struct {
 	compact bool
 	long    bool
 	recurse bool
+	byTime  bool
+	bySize  bool
+	stdout  io.Writer
 }
 
 func (cmd *ListCommand) Help() string {
@@ -58,6 +68,10 @@ func (cmd *ListCommand) list(client *Client, path string) int {
 	}
 
 	if len(infos) == 1 && infos[0].IsDir() {
+		if cmd.recurse {
+			return cmd.listTree(client, path, infos[0].Name())
+		}
+
 		// Single item found and it's a directory; list its contents
 		infos, err = client.ReadDir(infos[0].Name())
 		if err != nil {
@@ -71,51 +85,269 @@ func (cmd *ListCommand) list(client *Client, path string) int {
 	}
 
 	if cmd.recurse {
-		fmt.Println(path + ":")
+		fmt.Fprintln(cmd.output(), path+":")
+	}
+	cmd.print(client, infos)
+
+	var ret int
+	if cmd.recurse {
+		for _, info := range infos {
+			if !info.IsDir() {
+				continue
+			}
+			fmt.Fprintln(cmd.output(), "")
+			if code := cmd.listTree(client, info.Name(), info.Name()); code > ret {
+				ret = code
+			}
+		}
 	}
 
+	return ret
+}
+
+// listTree lists all directories below dir; directories that can't be listed
+// are reported and skipped
+func (cmd *ListCommand) listTree(client *Client, label, dir string) int {
 	var (
-		names []string
-		files = make(map[string]os.FileInfo)
+		ret     int
+		dirs    []string
+		entries = make(map[string][]os.FileInfo)
 	)
-	for _, info := range infos {
-		name := info.Name()
-		names = append(names, name)
-		files[name] = info
+	err := client.WalkDir(dir, func(name string, info os.FileInfo, err error) error {
+		if err != nil {
+			cmd.ui.Error(fmt.Sprintf("%s: %v", name, err))
+			ret = 1
+			return nil
+		}
+		if info.IsDir() {
+			if _, seen := entries[name]; !seen {
+				dirs = append(dirs, name)
+				entries[name] = nil
+			}
+		}
+		if len(dirs) > 0 && name != dirs[0] {
+			parent := filepath.Dir(name)
+			entries[parent] = append(entries[parent], info)
+		}
+		return nil
+	})
+	if err != nil {
+		cmd.ui.Error(err.Error())
+		return 1
 	}
 
-	sort.Strings(names)
-	for _, name := range names {
-		info := files[name]
-		var t = '-'
-		if info.IsDir() {
-			t = 'd'
+	for i, name := range dirs {
+		if i == 0 {
+			fmt.Fprintln(cmd.output(), label+":")
+		} else {
+			fmt.Fprintln(cmd.output(), "")
+			fmt.Fprintln(cmd.output(), name+":")
 		}
-		if cmd.long {
-			fmt.Printf("%c%s %s\n", t, info.Mode(), name)
+		cmd.print(client, entries[name])
+	}
+
+	return ret
+}
+
+// listEntry is a directory or secret with the metadata shown in long format
+type listEntry struct {
+	os.FileInfo
+	keys         int
+	size         int64
+	kind         string
+	version      int
+	modTime      time.Time
+	capabilities []string
+}
+
+// stat reads the secret and its KV version 2 metadata; the capabilities of
+// our token are only looked up if caps is set
+func (e *listEntry) stat(client *Client, caps bool) {
+	name := e.Name()
+	if e.IsDir() {
+		name += "/"
+	} else if secret, err := client.Read(name); err == nil && secret != nil {
+		e.keys = len(secret.Data)
+		if kind, ok := secret.Data[CodecTypeKey].(string); ok {
+			e.kind = kind
+			e.keys--
+		}
+		e.size, e.modTime, e.version = client.secretStat(name, secret.Data)
+	}
+	if caps {
+		var err error
+		if e.capabilities, err = client.Capabilities(name); err != nil {
+			Debugf("ls: capabilities %q: %v", name, err)
+		}
+	}
+}
+
+// mode formats the type and the capabilities of our token, "?" if they are
+// unknown
+func (e *listEntry) mode() string {
+	const letters = "crudls"
+	var (
+		out  = []byte{'-', '-', '-', '-', '-', '-', '-'}
+		caps = map[string]byte{
+			"create": 'c', "read": 'r', "update": 'u', "delete": 'd',
+			"list": 'l', "sudo": 's',
+		}
+	)
+	if e.IsDir() {
+		out[0] = 'd'
+	}
+	if e.capabilities == nil {
+		copy(out[1:], "??????")
+	}
+	for _, capability := range e.capabilities {
+		switch capability {
+		case "root":
+			copy(out[1:], letters)
+		case "deny":
+			copy(out[1:], "------")
+		default:
+			if c, ok := caps[capability]; ok {
+				out[1+strings.IndexByte(letters, c)] = c
+			}
+		}
+	}
+	return string(out)
+}
+
+// print the items, sorted by name unless sorting by time or size is
+// requested; in long format the metadata of secrets is shown
+func (cmd *ListCommand) print(client *Client, infos []os.FileInfo) {
+	entries := make([]*listEntry, len(infos))
+	for i, info := range infos {
+		entries[i] = &listEntry{FileInfo: info}
+	}
+	if cmd.long || cmd.byTime || cmd.bySize {
+		var (
+			wg   sync.WaitGroup
+			jobs = make(chan *listEntry)
+		)
+		for i := 0; i < walkParallel; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for entry := range jobs {
+					entry.stat(client, cmd.long)
+				}
+			}()
+		}
+		for _, entry := range entries {
+			jobs <- entry
+		}
+		close(jobs)
+		wg.Wait()
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		switch {
+		case cmd.bySize && a.size != b.size:
+			return a.size > b.size
+		case cmd.byTime && !cmd.bySize && !a.modTime.Equal(b.modTime):
+			return a.modTime.After(b.modTime)
+		}
+		return a.Name() < b.Name()
+	})
+
+	out := cmd.output()
+	if !cmd.long {
+		names := make([]string, len(entries))
+		for i, entry := range entries {
+			names[i] = entry.Name()
+		}
+		if width := cmd.width(); width > 0 {
+			printColumns(out, names, width)
 		} else {
-			fmt.Println(name)
+			for _, name := range names {
+				fmt.Fprintln(out, name)
+			}
 		}
+		return
 	}
 
-	if cmd.recurse {
-		for _, name := range names {
-			info := files[name]
-			if !info.IsDir() {
-				continue
+	w := tabwriter.NewWriter(out, 0, 8, 1, ' ', 0)
+	for _, entry := range entries {
+		var keys, size, kind, version, mtime string
+		if !entry.IsDir() {
+			keys = strconv.Itoa(entry.keys)
+			size = strconv.FormatInt(entry.size, 10)
+			kind = "-"
+			if entry.kind != "" {
+				kind = entry.kind
 			}
-			fmt.Println("")
-			if code := cmd.list(client, info.Name()); code != 0 {
-				return code
+			version = "-"
+			if entry.version > 0 {
+				version = "v" + strconv.Itoa(entry.version)
 			}
 		}
+		if !entry.modTime.IsZero() {
+			mtime = entry.modTime.Local().Format("Jan _2 15:04 2006")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", entry.mode(), keys, size, kind, version, mtime, entry.Name())
 	}
+	w.Flush()
+}
 
-	return 0
+// width returns the width for listing in columns, 0 to list one per line
+func (cmd *ListCommand) width() int {
+	if cmd.compact || cmd.stdout != nil || !IsTerminal(os.Stdout.Fd()) {
+		return 0
+	}
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	if width := terminalWidth(os.Stdout.Fd()); width > 0 {
+		return width
+	}
+	return 80
+}
+
+// printColumns prints the names in columns sorted down, like ls(1)
+func printColumns(w io.Writer, names []string, width int) {
+	if len(names) == 0 {
+		return
+	}
+	var size int
+	for _, name := range names {
+		if n := utf8.RuneCountInString(name) + 2; n > size {
+			size = n
+		}
+	}
+	cols := width / size
+	if cols < 1 {
+		cols = 1
+	}
+	rows := (len(names) + cols - 1) / cols
+	for row := 0; row < rows; row++ {
+		var line []string
+		for col := 0; col < cols; col++ {
+			i := col*rows + row
+			if i >= len(names) {
+				break
+			}
+			name := names[i]
+			if next := i + rows; next < len(names) {
+				name += strings.Repeat(" ", size-utf8.RuneCountInString(name))
+			}
+			line = append(line, name)
+		}
+		fmt.Fprintln(w, strings.Join(line, ""))
+	}
+}
+
+func (cmd *ListCommand) output() io.Writer {
+	if cmd.stdout == nil {
+		return os.Stdout
+	}
+	return cmd.stdout
 }
 
 func (cmd *ListCommand) listMounts(client *Client) int {
-	mounts, err := client.Sys().ListMounts()
+	mounts, err := client.backend().ListMounts()
 	if err != nil {
 		cmd.ui.Error(err.Error())
 		return 1
@@ -123,7 +355,7 @@ func (cmd *ListCommand) listMounts(client *Client) int {
 
 	var names []string
 	for name, mount := range mounts {
-		if mount.Type != "generic" {
+		if !isSecretMount(mount) {
 			continue
 		}
 		names = append(names, name)
@@ -133,7 +365,7 @@ func (cmd *ListCommand) listMounts(client *Client) int {
 	}
 
 	if cmd.recurse {
-		fmt.Println("/:")
+		fmt.Fprintln(cmd.output(), "/:")
 	}
 
 	sort.Strings(names)
@@ -141,13 +373,13 @@ func (cmd *ListCommand) listMounts(client *Client) int {
 		name = strings.TrimSuffix(name, "/")
 
 		if cmd.long {
-			fmt.Printf("drwxr-x--- %s\n", name)
+			fmt.Fprintf(cmd.output(), "drwxr-x--- %s\n", name)
 		} else {
-			fmt.Println(name)
+			fmt.Fprintln(cmd.output(), name)
 		}
 
 		if cmd.recurse {
-			fmt.Println("")
+			fmt.Fprintln(cmd.output(), "")
 			if code := cmd.list(client, name); code != 0 {
 				return code
 			}
@@ -170,9 +402,11 @@ func ListCommandFactory(ui cli.Ui) cli.CommandFactory {
 		}
 
 		cmd.fs = flag.NewFlagSet("ls", flag.ContinueOnError)
-		cmd.fs.BoolVar(&cmd.compact, "1", false, "list in compact format")
+		cmd.fs.BoolVar(&cmd.compact, "1", false, "list one entry per line")
 		cmd.fs.BoolVar(&cmd.long, "l", false, "list in long format")
 		cmd.fs.BoolVar(&cmd.recurse, "R", false, "recursively list subdirectories encountered")
+		cmd.fs.BoolVar(&cmd.byTime, "t", false, "sort by modification time, newest first")
+		cmd.fs.BoolVar(&cmd.bySize, "S", false, "sort by size, largest first")
 		cmd.fs.Usage = func() {
 			fmt.Print(cmd.Help())
 		}
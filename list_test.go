@@ -0,0 +1,120 @@
+package vc
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestListEntryMode(t *testing.T) {
+	for _, test := range []struct {
+		dir  bool
+		caps []string
+		want string
+	}{
+		{false, []string{"root"}, "-crudls"},
+		{true, []string{"read", "list"}, "d-r--l-"},
+		{false, []string{"create", "update", "patch"}, "-c-u---"},
+		{false, []string{"deny"}, "-------"},
+		{false, nil, "-??????"},
+	} {
+		key := "test"
+		if test.dir {
+			key += "/"
+		}
+		entry := &listEntry{FileInfo: &secretInfo{Path: "/secret/test", Key: key}, capabilities: test.caps}
+		if got := entry.mode(); got != test.want {
+			t.Fatalf("%v: expected %q, got %q", test.caps, test.want, got)
+		}
+	}
+}
+
+func TestPrintColumns(t *testing.T) {
+	buf := new(bytes.Buffer)
+	printColumns(buf, []string{"a", "bb", "ccc", "d", "e"}, 12)
+	if want := "a    d\nbb   e\nccc\n"; buf.String() != want {
+		t.Fatalf("expected %q, got %q", want, buf.String())
+	}
+}
+
+func TestListCommand(t *testing.T) {
+	_, client := testMemoryClient()
+	testWrite(t, client, "kv/app/small", map[string]interface{}{"a": "1"})
+	testWrite(t, client, "kv/app/large", map[string]interface{}{"a": "1", "b": strings.Repeat("x", 64)})
+	testWrite(t, client, "kv/app/small", map[string]interface{}{"a": "2"})
+	testWrite(t, client, "kv/app/dir/nested", map[string]interface{}{"a": "1"})
+	if err := client.writeFile("kv/app/conf", []byte("listen = 80\n"), nil, encodingGzip, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) []string {
+		t.Helper()
+		buf := new(bytes.Buffer)
+		command, _ := ListCommandFactory(cli.NewMockUi())()
+		cmd := command.(*ListCommand)
+		cmd.stdout = buf
+		cmd.setClient(client)
+		if code := cmd.Run(args); code != Success {
+			t.Fatalf("%v: expected return code %d, got %d", args, Success, code)
+		}
+		return strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	}
+
+	lines := run("-1", "kv/app")
+	if want := []string{"/kv/app/conf", "/kv/app/dir", "/kv/app/large", "/kv/app/small"}; strings.Join(lines, " ") != strings.Join(want, " ") {
+		t.Fatalf("expected %q, got %q", want, lines)
+	}
+
+	lines = run("-l", "kv/app")
+	if len(lines) != 4 {
+		t.Fatalf("expected 4 lines, got %q", lines)
+	}
+	for i, want := range [][]string{
+		{"-crudls", "4", "12", "file", "v1"},
+		{"dcrudls", "/kv/app/dir"},
+		{"-crudls", "2", "80", "-", "v1"},
+		{"-crudls", "1", "9", "-", "v2"},
+	} {
+		fields := strings.Fields(lines[i])
+		if len(fields) < len(want) || strings.Join(fields[:len(want)-1], " ") != strings.Join(want[:len(want)-1], " ") || !strings.Contains(lines[i], want[len(want)-1]) {
+			t.Fatalf("line %d: expected %q, got %q", i, want, lines[i])
+		}
+	}
+
+	lines = run("-S", "kv/app")
+	if lines[0] != "/kv/app/large" || lines[len(lines)-1] != "/kv/app/dir" {
+		t.Fatalf("expected sorting by size, got %q", lines)
+	}
+	lines = run("-t", "kv/app")
+	if lines[0] != "/kv/app/conf" || lines[1] != "/kv/app/small" || lines[2] != "/kv/app/large" {
+		t.Fatalf("expected sorting by time, got %q", lines)
+	}
+}
+
+// TestListCommandMountRefresh lists in long format while the mounts are
+// refreshed on every lookup, run with -race
+func TestListCommandMountRefresh(t *testing.T) {
+	defer func(refresh time.Duration) { mountRefresh = refresh }(mountRefresh)
+	mountRefresh = 0
+
+	_, client := testMemoryClient()
+	for i := 0; i < 4*walkParallel; i++ {
+		testWrite(t, client, fmt.Sprintf("kv/many/%03d", i), map[string]interface{}{"a": "1"})
+	}
+
+	buf := new(bytes.Buffer)
+	command, _ := ListCommandFactory(cli.NewMockUi())()
+	cmd := command.(*ListCommand)
+	cmd.stdout = buf
+	cmd.setClient(client)
+	if code := cmd.Run([]string{"-l", "kv/many"}); code != Success {
+		t.Fatalf("expected return code %d, got %d", Success, code)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 4*walkParallel {
+		t.Fatalf("expected %d lines, got %d", 4*walkParallel, lines)
+	}
+}
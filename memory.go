@@ -0,0 +1,528 @@
+package vc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// MemoryBackend is a Backend that keeps secrets in memory. It mimics the
+// generic and key/value (version 1 and 2) secrets engines of Vault, which
+// makes it suitable for testing.
+//
+// MemoryBackend also implements http.Handler serving the parts of the Vault
+// HTTP API used by vc, so it can be used with net/http/httptest to fake a
+// Vault server.
+type MemoryBackend struct {
+	mutex   sync.RWMutex
+	mounts  map[string]*api.MountOutput
+	secrets map[string]map[string]interface{}
+	kv      map[string]*memorySecret
+}
+
+// memorySecret is a KV version 2 secret
+type memorySecret struct {
+	created  time.Time
+	updated  time.Time
+	versions []*memoryVersion
+}
+
+// memoryVersion is a version of a KV version 2 secret
+type memoryVersion struct {
+	data      map[string]interface{}
+	created   time.Time
+	deleted   time.Time
+	destroyed bool
+}
+
+// NewMemoryBackend returns a MemoryBackend with a KV version 1 mount at secret/
+func NewMemoryBackend() *MemoryBackend {
+	b := &MemoryBackend{
+		mounts:  make(map[string]*api.MountOutput),
+		secrets: make(map[string]map[string]interface{}),
+		kv:      make(map[string]*memorySecret),
+	}
+	b.Mount("secret", kvType, map[string]string{"version": "1"})
+	return b
+}
+
+// Mount a secrets engine of the given type at path
+func (b *MemoryBackend) Mount(path, kind string, options map[string]string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.mounts[strings.Trim(path, "/")+"/"] = &api.MountOutput{
+		Type:    kind,
+		Options: options,
+	}
+}
+
+// route resolves the mount for path, rest is the path relative to the mount
+func (b *MemoryBackend) route(path string) (mount string, version int, rest string, err error) {
+	path = strings.Trim(path, "/")
+	for prefix := range b.mounts {
+		if strings.HasPrefix(path+"/", prefix) && len(prefix) > len(mount) {
+			mount = prefix
+		}
+	}
+	if mount == "" {
+		return "", 0, "", fmt.Errorf("no handler for route '%s'", path)
+	}
+	if !isSecretMount(b.mounts[mount]) {
+		return "", 0, "", fmt.Errorf("unsupported secrets engine %q at '%s'", b.mounts[mount].Type, mount)
+	}
+	version = kvVersion(b.mounts[mount])
+	rest = strings.Trim(strings.TrimPrefix(path+"/", mount), "/")
+	return
+}
+
+// routeKV splits a KV version 2 path relative to the mount in operation and key
+func (b *MemoryBackend) routeKV(rest string) (op, key string) {
+	if i := strings.IndexByte(rest, '/'); i > -1 {
+		return rest[:i], rest[i+1:]
+	}
+	return rest, ""
+}
+
+// Read a secret
+func (b *MemoryBackend) Read(path string) (*api.Secret, error) {
+	return b.ReadWithData(path, nil)
+}
+
+// ReadWithData reads a secret, the only supported parameter is "version"
+func (b *MemoryBackend) ReadWithData(path string, data map[string][]string) (*api.Secret, error) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	mount, version, rest, err := b.route(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if version == 1 {
+		values, ok := b.secrets[mount+rest]
+		if !ok {
+			return nil, nil
+		}
+		return &api.Secret{Data: copyData(values)}, nil
+	}
+
+	op, key := b.routeKV(rest)
+	s := b.kv[mount+key]
+	if s == nil {
+		if op != "data" && op != "metadata" {
+			return nil, fmt.Errorf("no handler for route '%s'", path)
+		}
+		return nil, nil
+	}
+
+	switch op {
+	case "data":
+		n := len(s.versions)
+		if v, ok := data["version"]; ok && len(v) > 0 && v[0] != "0" {
+			if n, err = strconv.Atoi(v[0]); err != nil {
+				return nil, fmt.Errorf("invalid version %q", v[0])
+			}
+		}
+		if n < 1 || n > len(s.versions) {
+			return nil, nil
+		}
+		v := s.versions[n-1]
+		secret := &api.Secret{Data: map[string]interface{}{
+			"data":     nil,
+			"metadata": v.metadata(n),
+		}}
+		if v.deleted.IsZero() && !v.destroyed {
+			secret.Data["data"] = copyData(v.data)
+		}
+		return secret, nil
+
+	case "metadata":
+		versions := make(map[string]interface{})
+		for i, v := range s.versions {
+			versions[strconv.Itoa(i+1)] = v.metadata(0)
+		}
+		return &api.Secret{Data: map[string]interface{}{
+			"current_version": len(s.versions),
+			"oldest_version":  0,
+			"created_time":    s.created.Format(time.RFC3339Nano),
+			"updated_time":    s.updated.Format(time.RFC3339Nano),
+			"versions":        versions,
+		}}, nil
+
+	default:
+		return nil, fmt.Errorf("no handler for route '%s'", path)
+	}
+}
+
+// Write a secret
+func (b *MemoryBackend) Write(path string, data map[string]interface{}) (*api.Secret, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	mount, version, rest, err := b.route(path)
+	if err != nil {
+		return nil, err
+	}
+	if rest == "" {
+		return nil, errors.New("missing secret path")
+	}
+
+	if version == 1 {
+		if data, err = jsonData(data); err != nil {
+			return nil, err
+		}
+		b.secrets[mount+rest] = data
+		return nil, nil
+	}
+
+	op, key := b.routeKV(rest)
+	if key == "" {
+		return nil, errors.New("missing secret path")
+	}
+	now := time.Now().UTC()
+	s := b.kv[mount+key]
+
+	switch op {
+	case "data":
+		values, ok := data["data"].(map[string]interface{})
+		if !ok {
+			return nil, errors.New("no data provided")
+		}
+		if values, err = jsonData(values); err != nil {
+			return nil, err
+		}
+		if s == nil {
+			s = &memorySecret{created: now}
+			b.kv[mount+key] = s
+		}
+		s.updated = now
+		s.versions = append(s.versions, &memoryVersion{
+			data:    values,
+			created: now,
+		})
+		return &api.Secret{Data: s.versions[len(s.versions)-1].metadata(len(s.versions))}, nil
+
+	case "metadata":
+		if s == nil {
+			s = &memorySecret{created: now, updated: now}
+			b.kv[mount+key] = s
+		}
+		return nil, nil
+
+	case "delete", "undelete", "destroy":
+		if s == nil {
+			return nil, nil
+		}
+		for _, n := range toInts(data["versions"]) {
+			if n < 1 || n > len(s.versions) {
+				continue
+			}
+			switch v := s.versions[n-1]; op {
+			case "delete":
+				if v.deleted.IsZero() {
+					v.deleted = now
+				}
+			case "undelete":
+				if !v.destroyed {
+					v.deleted = time.Time{}
+				}
+			case "destroy":
+				v.data = nil
+				v.destroyed = true
+			}
+		}
+		return nil, nil
+
+	default:
+		return nil, fmt.Errorf("no handler for route '%s'", path)
+	}
+}
+
+// Delete a secret
+func (b *MemoryBackend) Delete(path string) (*api.Secret, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	mount, version, rest, err := b.route(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if version == 1 {
+		delete(b.secrets, mount+rest)
+		return nil, nil
+	}
+
+	op, key := b.routeKV(rest)
+	switch op {
+	case "data":
+		if s := b.kv[mount+key]; s != nil && len(s.versions) > 0 {
+			if v := s.versions[len(s.versions)-1]; v.deleted.IsZero() {
+				v.deleted = time.Now().UTC()
+			}
+		}
+	case "metadata":
+		delete(b.kv, mount+key)
+	default:
+		return nil, fmt.Errorf("no handler for route '%s'", path)
+	}
+	return nil, nil
+}
+
+// List secrets
+func (b *MemoryBackend) List(path string) (*api.Secret, error) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	mount, version, rest, err := b.route(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	if version == 1 {
+		for name := range b.secrets {
+			names = append(names, name)
+		}
+	} else {
+		var op string
+		if op, rest = b.routeKV(rest); op != "metadata" {
+			return nil, fmt.Errorf("no handler for route '%s'", path)
+		}
+		for name := range b.kv {
+			names = append(names, name)
+		}
+	}
+
+	prefix := mount
+	if rest != "" {
+		prefix += rest + "/"
+	}
+
+	var (
+		keys []interface{}
+		seen = make(map[string]bool)
+	)
+	sort.Strings(names)
+	for _, name := range names {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		key := strings.TrimPrefix(name, prefix)
+		if i := strings.IndexByte(key, '/'); i > -1 {
+			key = key[:i+1]
+		}
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	return &api.Secret{Data: map[string]interface{}{
+		"keys": keys,
+	}}, nil
+}
+
+// ListMounts lists the mounted secrets engines
+func (b *MemoryBackend) ListMounts() (map[string]*api.MountOutput, error) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	mounts := make(map[string]*api.MountOutput, len(b.mounts))
+	for name, mount := range b.mounts {
+		copied := *mount
+		mounts[name] = &copied
+	}
+	return mounts, nil
+}
+
+// LookupSelf returns a root token
+func (b *MemoryBackend) LookupSelf() (*api.Secret, error) {
+	return &api.Secret{Data: map[string]interface{}{
+		"display_name": "memory",
+		"policies":     []interface{}{"root"},
+	}}, nil
+}
+
+// CapabilitiesSelf returns the capabilities of a root token
+func (b *MemoryBackend) CapabilitiesSelf(path string) ([]string, error) {
+	return []string{"root"}, nil
+}
+
+// ServeHTTP implements a minimal Vault HTTP API
+func (b *MemoryBackend) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var (
+		path   = strings.TrimPrefix(r.URL.Path, "/v1/")
+		query  = r.URL.Query()
+		secret *api.Secret
+		err    error
+	)
+	Debugf("memory: %s %s", r.Method, r.URL)
+
+	switch {
+	case path == "sys/leader":
+		memoryResponse(w, http.StatusOK, map[string]interface{}{
+			"ha_enabled": false,
+			"is_self":    true,
+		})
+		return
+
+	case path == "sys/mounts" && r.Method == http.MethodGet:
+		var mounts map[string]*api.MountOutput
+		if mounts, err = b.ListMounts(); err == nil {
+			data := make(map[string]interface{}, len(mounts))
+			for name, mount := range mounts {
+				data[name] = mount
+			}
+			secret = &api.Secret{Data: data}
+		}
+
+	case path == "auth/token/lookup-self":
+		secret, err = b.LookupSelf()
+
+	case path == "sys/capabilities-self":
+		var (
+			capabilities []string
+			request      struct{ Path string }
+		)
+		if err = json.NewDecoder(r.Body).Decode(&request); err == nil {
+			if capabilities, err = b.CapabilitiesSelf(request.Path); err == nil {
+				secret = &api.Secret{Data: map[string]interface{}{
+					"capabilities": capabilities,
+					request.Path:   capabilities,
+				}}
+			}
+		}
+
+	case r.Method == "LIST" || (r.Method == http.MethodGet && query.Get("list") == "true"):
+		secret, err = b.List(path)
+
+	case r.Method == http.MethodGet:
+		secret, err = b.ReadWithData(path, query)
+
+	case r.Method == http.MethodPut || r.Method == http.MethodPost:
+		data := make(map[string]interface{})
+		dec := json.NewDecoder(r.Body)
+		dec.UseNumber()
+		if err = dec.Decode(&data); err == nil {
+			secret, err = b.Write(path, data)
+		}
+
+	case r.Method == http.MethodDelete:
+		secret, err = b.Delete(path)
+
+	default:
+		memoryResponse(w, http.StatusMethodNotAllowed, map[string]interface{}{
+			"errors": []string{"unsupported operation"},
+		})
+		return
+	}
+
+	switch {
+	case err != nil:
+		memoryResponse(w, http.StatusBadRequest, map[string]interface{}{
+			"errors": []string{err.Error()},
+		})
+	case secret == nil && (r.Method == http.MethodGet || r.Method == "LIST"):
+		memoryResponse(w, http.StatusNotFound, map[string]interface{}{
+			"errors": []string{},
+		})
+	case secret == nil:
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		memoryResponse(w, http.StatusOK, secret)
+	}
+}
+
+func memoryResponse(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// metadata of a version, as returned by Vault; version 0 omits the version
+func (v *memoryVersion) metadata(version int) map[string]interface{} {
+	meta := map[string]interface{}{
+		"created_time":  v.created.Format(time.RFC3339Nano),
+		"deletion_time": "",
+		"destroyed":     v.destroyed,
+	}
+	if !v.deleted.IsZero() {
+		meta["deletion_time"] = v.deleted.Format(time.RFC3339Nano)
+	}
+	if version > 0 {
+		meta["version"] = version
+	}
+	return meta
+}
+
+// jsonData returns a copy of secret data as Vault returns it: encoded as JSON
+// and decoded with numbers as json.Number
+func jsonData(data map[string]interface{}) (map[string]interface{}, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err = dec.Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// copyData returns a deep copy of secret data
+func copyData(data map[string]interface{}) map[string]interface{} {
+	if data == nil {
+		return nil
+	}
+	return copyValue(data).(map[string]interface{})
+}
+
+func copyValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			copied[key] = copyValue(value)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, value := range v {
+			copied[i] = copyValue(value)
+		}
+		return copied
+	case []byte:
+		return append([]byte(nil), v...)
+	default:
+		return v
+	}
+}
+
+// toInts converts a list of decoded JSON numbers to a slice of int
+func toInts(v interface{}) (ints []int) {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Slice {
+		return
+	}
+	for i := 0; i < value.Len(); i++ {
+		ints = append(ints, toInt(value.Index(i).Interface()))
+	}
+	return
+}
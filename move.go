@@ -12,34 +12,60 @@ import (
 // MoveCommand can display (structured) secrets
 type MoveCommand struct {
 	baseCommand
-	fs    *flag.FlagSet
-	force bool
+	fs      *flag.FlagSet
+	force   bool
+	recurse bool
 }
 
 func (cmd *MoveCommand) Help() string {
-	return "Usage: vc [<options>] mv <source secret> <target secret>\n\nOptions:\n" + defaults(cmd.fs)
+	return "Usage: vc [<options>] mv <source secret> <target secret>\n" +
+		"       vc [<options>] mv <source secret> [... <source secret>] <target directory>/\n\nOptions:\n" + defaults(cmd.fs)
 }
 
 func (cmd *MoveCommand) Run(args []string) int {
 	if err := cmd.fs.Parse(args); err != nil {
 		return 1
 	}
-	if args = cmd.fs.Args(); len(args) != 2 {
+	if args = cmd.fs.Args(); len(args) < 2 {
 		return cli.RunResultHelp
 	}
 
-	if args[0] == args[1] {
-		return 0
-	}
-
 	client, err := cmd.Client()
 	if err != nil {
 		cmd.ui.Error(err.Error())
 		return 2
 	}
 
+	sources, target := args[:len(args)-1], args[len(args)-1]
+	if cmd.hasGlobs(client, sources) {
+		if sources, err = cmd.globs(client, sources); err != nil {
+			cmd.ui.Error(fmt.Sprintf("error: %v", err))
+			return 1
+		}
+		if ok, err := cmd.confirmExpansion("move", sources, cmd.force); err != nil {
+			cmd.ui.Error(err.Error())
+			return 1
+		} else if !ok {
+			return 0
+		}
+	}
+
+	// Multiple sources or a target ending in a slash; move into directory
+	if len(sources) > 1 || strings.HasSuffix(target, "/") {
+		return cmd.copyInto(client, sources, target, cmd.force, cmd.recurse, true)
+	}
+
+	args = []string{sources[0], target}
+	if args[0] == args[1] {
+		return 0
+	}
+
+	if cmd.recurse {
+		return cmd.copyTree(client, args[0], args[1], cmd.force, true)
+	}
+
 	// Read secret at old path
-	secret, err := client.Logical().Read(strings.TrimLeft(args[0], "/"))
+	secret, err := client.Read(args[0])
 	if err != nil {
 		cmd.ui.Error(err.Error())
 		return 1
@@ -51,7 +77,7 @@ func (cmd *MoveCommand) Run(args []string) int {
 
 	// Check if secret at new path exists, unless force is enabled
 	if !cmd.force {
-		oldSecret, oerr := client.Logical().Read(strings.TrimLeft(args[1], "/"))
+		oldSecret, oerr := client.Read(args[1])
 		if oerr != nil {
 			cmd.ui.Error(oerr.Error())
 			return 1
@@ -67,14 +93,14 @@ func (cmd *MoveCommand) Run(args []string) int {
 		}
 	}
 
-	// Write secret at new path
-	if _, err = client.Logical().Write(strings.TrimLeft(args[1], "/"), secret.Data); err != nil {
+	// Write secret at new path, chunked files with their chunks
+	if err = client.copySecret(args[0], args[1], secret.Data); err != nil {
 		cmd.ui.Error(err.Error())
 		return 1
 	}
 
 	// Delete secret at old path
-	if _, err = client.Logical().Delete(strings.TrimLeft(args[0], "/")); err != nil {
+	if err = client.deleteFile(args[0], secret.Data); err != nil {
 		cmd.ui.Error(err.Error())
 		return 1
 	}
@@ -96,6 +122,7 @@ func MoveCommandFactory(ui cli.Ui) cli.CommandFactory {
 
 		cmd.fs = flag.NewFlagSet("mv", flag.ContinueOnError)
 		cmd.fs.BoolVar(&cmd.force, "f", false, "force overwrite")
+		cmd.fs.BoolVar(&cmd.recurse, "R", false, "recursively move secrets below the source path")
 		cmd.fs.Usage = func() {
 			fmt.Print(cmd.Help())
 		}
@@ -0,0 +1,226 @@
+package vc
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// treeSummary counts the outcome of an operation on a tree of secrets
+type treeSummary struct {
+	done    int
+	skipped int
+	failed  int
+}
+
+func (s treeSummary) String() string {
+	return fmt.Sprintf("%d done, %d skipped, %d failed", s.done, s.skipped, s.failed)
+}
+
+// secretPaths returns the sorted paths of all secrets below root, relative to
+// root. If root itself is a secret, it is returned as empty path.
+func (c *Client) secretPaths(root string) (paths []string, err error) {
+	root = c.abspath(root)
+
+	err = c.Walk(root, func(name string, err error) error {
+		if name == root && os.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if name == root {
+			paths = append(paths, "")
+			return nil
+		}
+		rel, err := filepath.Rel(root, name)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, rel)
+		return nil
+	})
+	return
+}
+
+// overwrite checks if the secret at path may be written to; if it exists,
+// the user is asked for confirmation unless force is enabled
+func (cmd *baseCommand) overwrite(client *Client, path string, force bool) (bool, error) {
+	if force {
+		return true, nil
+	}
+
+	secret, err := client.Read(path)
+	if err != nil {
+		return false, err
+	}
+	if secret == nil {
+		return true, nil
+	}
+	if !IsTerminal(os.Stdout.Fd()) {
+		return false, fmt.Errorf("secret at %q already exists", path)
+	}
+	return confirmf("secret at %s already exists, overwrite?", path), nil
+}
+
+// copyPair is the source and destination of a copy
+type copyPair struct {
+	src, dst string
+}
+
+// treePairs lists all secrets below src, with their destination below dst;
+// chunks are copied with the file they belong to
+func (cmd *baseCommand) treePairs(client *Client, src, dst string) ([]copyPair, error) {
+	paths, err := client.secretPaths(src)
+	if err != nil {
+		return nil, err
+	}
+
+	var pairs []copyPair
+	for _, rel := range paths {
+		if !isChunkPath(rel) {
+			pairs = append(pairs, copyPair{path.Join(src, rel), path.Join(dst, rel)})
+		}
+	}
+	return pairs, nil
+}
+
+// copyTree copies or moves all secrets below src to dst, preserving their
+// relative paths
+func (cmd *baseCommand) copyTree(client *Client, src, dst string, force, move bool) int {
+	pairs, err := cmd.treePairs(client, src, dst)
+	if err != nil {
+		cmd.ui.Error(err.Error())
+		return ServerError
+	}
+	if len(pairs) == 0 {
+		cmd.ui.Error(fmt.Sprintf("no secrets at %q", src))
+		return SyntaxError
+	}
+
+	return cmd.copyPairs(client, pairs, force, move)
+}
+
+// copyInto copies or moves sources into directory dir
+func (cmd *baseCommand) copyInto(client *Client, sources []string, dir string, force, recurse, move bool) int {
+	var pairs []copyPair
+	for _, src := range sources {
+		dst := path.Join(dir, path.Base(src))
+		if !recurse {
+			pairs = append(pairs, copyPair{src, dst})
+			continue
+		}
+
+		more, err := cmd.treePairs(client, src, dst)
+		if err != nil {
+			cmd.ui.Error(err.Error())
+			return ServerError
+		}
+		if len(more) == 0 {
+			cmd.ui.Error(fmt.Sprintf("no secrets at %q", src))
+			return SyntaxError
+		}
+		pairs = append(pairs, more...)
+	}
+
+	return cmd.copyPairs(client, pairs, force, move)
+}
+
+// copyPairs copies or moves secrets and reports a summary
+func (cmd *baseCommand) copyPairs(client *Client, pairs []copyPair, force, move bool) int {
+	var summary treeSummary
+	for _, pair := range pairs {
+		if pair.src == pair.dst {
+			summary.skipped++
+			continue
+		}
+
+		ok, err := cmd.overwrite(client, pair.dst, force)
+		if err != nil {
+			cmd.ui.Error(err.Error())
+			summary.failed++
+			continue
+		} else if !ok {
+			summary.skipped++
+			continue
+		}
+
+		secret, err := client.Read(pair.src)
+		if err != nil {
+			cmd.ui.Error(fmt.Sprintf("%s: %v", pair.src, err))
+			summary.failed++
+			continue
+		} else if secret == nil {
+			cmd.ui.Error(fmt.Sprintf("no secret at %q", pair.src))
+			summary.failed++
+			continue
+		}
+
+		if err = client.copySecret(pair.src, pair.dst, secret.Data); err != nil {
+			cmd.ui.Error(fmt.Sprintf("%s: %v", pair.dst, err))
+			summary.failed++
+			continue
+		}
+		if move {
+			if err = client.deleteFile(pair.src, secret.Data); err != nil {
+				cmd.ui.Error(fmt.Sprintf("%s: %v", pair.src, err))
+				summary.failed++
+				continue
+			}
+		}
+
+		Debugf("copy: %s -> %s", pair.src, pair.dst)
+		summary.done++
+	}
+
+	if move {
+		cmd.ui.Info("moved: " + summary.String())
+	} else {
+		cmd.ui.Info("copied: " + summary.String())
+	}
+	if summary.failed > 0 {
+		return ServerError
+	}
+	return Success
+}
+
+// deleteTree removes all secrets below root
+func (cmd *baseCommand) deleteTree(client *Client, root string, force bool) int {
+	paths, err := client.secretPaths(root)
+	if err != nil {
+		cmd.ui.Error(err.Error())
+		return ServerError
+	}
+	if len(paths) == 0 {
+		cmd.ui.Error(fmt.Sprintf("no secrets at %q", root))
+		return SyntaxError
+	}
+
+	if !force {
+		if !IsTerminal(os.Stdout.Fd()) {
+			cmd.ui.Error(fmt.Sprintf("refusing to remove %d secrets at %q without force", len(paths), root))
+			return SystemError
+		}
+		if !confirmf("remove %d secrets at %s?", len(paths), root) {
+			return Success
+		}
+	}
+
+	var summary treeSummary
+	for _, rel := range paths {
+		name := path.Join(root, rel)
+		if _, err = client.Delete(name); err != nil {
+			cmd.ui.Error(fmt.Sprintf("%s: %v", name, err))
+			summary.failed++
+			continue
+		}
+		Debugf("delete: %s", name)
+		summary.done++
+	}
+
+	cmd.ui.Info(fmt.Sprintf("%s: removed: %s", root, summary))
+	if summary.failed > 0 {
+		return ServerError
+	}
+	return Success
+}
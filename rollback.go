@@ -0,0 +1,99 @@
+package vc
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/mitchellh/cli"
+)
+
+// RollbackCommand restores a previous version of a secret
+type RollbackCommand struct {
+	baseCommand
+	fs *flag.FlagSet
+}
+
+func (cmd *RollbackCommand) Help() string {
+	return "Usage: vc rollback <secret path> <version>\n\nOptions:\n" + defaults(cmd.fs)
+}
+
+func (cmd *RollbackCommand) Run(args []string) int {
+	if err := cmd.fs.Parse(args); err != nil {
+		return SyntaxError
+	}
+	if args = cmd.fs.Args(); len(args) != 2 {
+		return Help
+	}
+
+	version, err := strconv.Atoi(args[1])
+	if err != nil || version < 1 {
+		cmd.ui.Error(fmt.Sprintf("error: invalid version %q", args[1]))
+		return SyntaxError
+	}
+
+	client, err := cmd.Client()
+	if err != nil {
+		cmd.ui.Error(err.Error())
+		return ClientError
+	}
+
+	meta, err := client.Metadata(args[0])
+	if err == ErrNotVersioned {
+		cmd.ui.Error(fmt.Sprintf("error: %s: %v", args[0], err))
+		return SyntaxError
+	} else if err != nil {
+		cmd.ui.Error(err.Error())
+		return ServerError
+	}
+	if meta == nil {
+		cmd.ui.Error(fmt.Sprintf("error: %s: secret not found", args[0]))
+		return SyntaxError
+	}
+	if version == meta.CurrentVersion {
+		cmd.ui.Warn(fmt.Sprintf("%s: version %d is the current version", args[0], version))
+		return Success
+	}
+
+	// Read the data of the old version, this fails for deleted and destroyed
+	// versions
+	secret, err := client.ReadVersion(args[0], version)
+	if err != nil {
+		cmd.ui.Error(err.Error())
+		return ServerError
+	}
+	if secret == nil {
+		cmd.ui.Error(fmt.Sprintf("error: %s: version %d not found, deleted or destroyed", args[0], version))
+		return SyntaxError
+	}
+
+	// Write the old data as new version
+	if _, err = client.Write(args[0], secret.Data); err != nil {
+		cmd.ui.Error(err.Error())
+		return ServerError
+	}
+
+	cmd.ui.Info(fmt.Sprintf("secret at %s rolled back to version %d", args[0], version))
+	return Success
+}
+
+func (cmd *RollbackCommand) Synopsis() string {
+	return "restore a previous version of a secret"
+}
+
+func RollbackCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		cmd := &RollbackCommand{
+			baseCommand: baseCommand{
+				ui: ui,
+			},
+		}
+
+		cmd.fs = flag.NewFlagSet("rollback", flag.ContinueOnError)
+		cmd.fs.Usage = func() {
+			fmt.Print(cmd.Help())
+		}
+
+		return cmd, nil
+	}
+}
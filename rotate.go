@@ -0,0 +1,167 @@
+package vc
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+// RotateCommand replaces a key with a newly generated value, keeping the
+// previous value
+type RotateCommand struct {
+	baseCommand
+	fs       *flag.FlagSet
+	gen      generator
+	previous string
+	hook     string
+	force    bool
+}
+
+// rotateHookInput is passed to the hook command on stdin
+type rotateHookInput struct {
+	Path string                 `json:"path"`
+	Key  string                 `json:"key"`
+	Old  map[string]interface{} `json:"old"`
+	New  map[string]interface{} `json:"new"`
+}
+
+func (cmd *RotateCommand) Help() string {
+	return "Usage: vc rotate [<options>] <secret path> <key>\n\nOptions:\n" + defaults(cmd.fs)
+}
+
+func (cmd *RotateCommand) Run(args []string) int {
+	if err := cmd.fs.Parse(args); err != nil {
+		return SyntaxError
+	}
+	if args = cmd.fs.Args(); len(args) != 2 {
+		return Help
+	}
+	path, key := args[0], args[1]
+
+	values, err := cmd.gen.generate(path, key)
+	if err != nil {
+		cmd.ui.Error(fmt.Sprintf("error: %v", err))
+		return SyntaxError
+	}
+
+	client, err := cmd.Client()
+	if err != nil {
+		cmd.ui.Error(err.Error())
+		return ClientError
+	}
+
+	_, version, err := client.kvPath(path, "data")
+	if err != nil {
+		cmd.ui.Error(err.Error())
+		return ServerError
+	}
+	if cmd.previous == "" && version == 1 && !cmd.force {
+		cmd.ui.Error(fmt.Sprintf("error: %s: refusing to discard the previous value on a KV version 1 mount without force", path))
+		return SyntaxError
+	}
+
+	secret, err := client.Read(path)
+	if err != nil {
+		cmd.ui.Error(err.Error())
+		return ServerError
+	}
+	if secret == nil {
+		cmd.ui.Error(fmt.Sprintf("error: %s: secret not found", path))
+		return SyntaxError
+	}
+	if _, ok := secret.Data[key]; !ok {
+		cmd.ui.Error(fmt.Sprintf("error: %s: key %q not found, use gen for new keys", path, key))
+		return SyntaxError
+	}
+
+	var (
+		data  = make(map[string]interface{})
+		input = rotateHookInput{
+			Path: path,
+			Key:  key,
+			Old:  make(map[string]interface{}),
+			New:  make(map[string]interface{}),
+		}
+	)
+	for k, v := range secret.Data {
+		data[k] = v
+	}
+	for _, k := range cmd.gen.keys(key) {
+		if old, ok := secret.Data[k]; ok {
+			input.Old[k] = old
+			if cmd.previous != "" {
+				data[k+cmd.previous] = old
+			}
+		}
+		data[k] = values[k]
+		input.New[k] = values[k]
+	}
+
+	if _, err = client.Write(path, data); err != nil {
+		cmd.ui.Error(err.Error())
+		return ServerError
+	}
+
+	if cmd.hook != "" {
+		if err = cmd.runHook(input); err != nil {
+			cmd.ui.Error(fmt.Sprintf("error: hook: %v", err))
+			if _, err = client.Write(path, secret.Data); err != nil {
+				cmd.ui.Error(fmt.Sprintf("error: %s: rollback failed: %v", path, err))
+				return ServerError
+			}
+			cmd.ui.Warn(fmt.Sprintf("%s: rolled back", path))
+			return SystemError
+		}
+	}
+
+	cmd.ui.Info(fmt.Sprintf("%s: rotated %s", path, strings.Join(cmd.gen.keys(key), ", ")))
+	return Success
+}
+
+// runHook runs the hook command with the old and new values as JSON on stdin
+func (cmd *RotateCommand) runHook(input rotateHookInput) error {
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(input); err != nil {
+		return err
+	}
+
+	Debugf("rotate: hook %q", cmd.hook)
+	hook := exec.Command("sh", "-c", cmd.hook)
+	hook.Stdin = buf
+	hook.Stdout = os.Stdout
+	hook.Stderr = os.Stderr
+	return hook.Run()
+}
+
+func (cmd *RotateCommand) Synopsis() string {
+	return "replace a key with a generated value"
+}
+
+func RotateCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		cmd := &RotateCommand{
+			baseCommand: baseCommand{
+				ui: ui,
+			},
+		}
+
+		cmd.fs = flag.NewFlagSet("rotate", flag.ContinueOnError)
+		cmd.fs.BoolVar(&cmd.force, "f", false, "discard the previous value on KV version 1 mounts if -previous is empty")
+		cmd.fs.StringVar(&cmd.previous, "previous", "_previous", "suffix of the key that keeps the previous value, if empty only prior versions keep it (KV version 2)")
+		cmd.fs.StringVar(&cmd.hook, "hook", "", "shell command to apply the change, gets the old and new values as JSON on stdin; the secret is rolled back if it fails")
+		cmd.gen.flags(cmd.fs)
+		cmd.fs.Usage = func() {
+			fmt.Print(cmd.Help())
+		}
+
+		return cmd, nil
+	}
+}
@@ -0,0 +1,77 @@
+package vc
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotateCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vc-test")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer os.RemoveAll(dir)
+	input := filepath.Join(dir, "input.json")
+
+	_, client := testMemoryClient()
+	testWrite(t, client, "kv/app/db", map[string]interface{}{"user": "app", "password": "first"})
+	testWrite(t, client, "secret/app/db", map[string]interface{}{"user": "app", "password": "first"})
+
+	for _, test := range []testCommand{
+		testCommand{
+			Factory: RotateCommandFactory,
+			Args:    []string{"--help"},
+			Code:    Success,
+		},
+		testCommand{
+			Factory: RotateCommandFactory,
+			Args:    []string{"kv/app/db", "missing"},
+			Code:    SyntaxError,
+			Client:  client,
+		},
+		testCommand{
+			Factory: RotateCommandFactory,
+			Args:    []string{"-previous", "", "secret/app/db", "password"},
+			Code:    SyntaxError,
+			Client:  client,
+		},
+		testCommand{
+			Factory: RotateCommandFactory,
+			Args:    []string{"-hook", "cat > " + input, "kv/app/db", "password"},
+			Code:    Success,
+			Client:  client,
+		},
+		testCommand{
+			// Failing hook rolls back
+			Factory: RotateCommandFactory,
+			Args:    []string{"-hook", "exit 1", "secret/app/db", "password"},
+			Code:    SystemError,
+			Client:  client,
+		},
+	} {
+		testCommandRun(t, test)
+	}
+
+	secret, err := client.Read("kv/app/db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	password, _ := secret.Data["password"].(string)
+	if password == "first" || len(password) != 32 || secret.Data["password_previous"] != "first" || secret.Data["user"] != "app" {
+		t.Fatalf("expected rotated password, got %+v", secret.Data)
+	}
+	if b, err := ioutil.ReadFile(input); err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(string(b), `"old":{"password":"first"}`) || !strings.Contains(string(b), password) {
+		t.Fatalf("unexpected hook input %s", b)
+	}
+
+	if secret, err = client.Read("secret/app/db"); err != nil {
+		t.Fatal(err)
+	} else if secret.Data["password"] != "first" || secret.Data["password_previous"] != nil {
+		t.Fatalf("expected secret to be rolled back, got %+v", secret.Data)
+	}
+}
@@ -66,7 +66,7 @@ func (cmd *ShellCommand) Run(args []string) int {
 		client.Path = "/"
 	}
 
-	secret, err := client.Auth().Token().LookupSelf()
+	secret, err := client.backend().LookupSelf()
 	if err != nil {
 		cmd.ui.Error(err.Error())
 		return 1
@@ -178,6 +178,10 @@ exit:
 
 func (cmd *ShellCommand) prompt() string {
 	cmd.host = "vault"
+	if cmd.c.Client == nil {
+		// Not talking to the Vault API
+		cmd.hostInfoProblematic = true
+	}
 	if !cmd.hostInfoProblematic {
 		leader, err := cmd.c.Sys().Leader()
 		if err != nil {
@@ -197,6 +201,10 @@ func (cmd *ShellCommand) expandArgs(args []string) string {
 	for i, arg := range args {
 		if !strings.HasPrefix(arg, "-") {
 			args[i] = cmd.c.abspath(arg)
+			if strings.HasSuffix(arg, "/") && args[i] != "/" {
+				// Keep directory targets (cp, mv) intact
+				args[i] += "/"
+			}
 		}
 	}
 	return strings.Join(args, " ")
@@ -204,12 +212,15 @@ func (cmd *ShellCommand) expandArgs(args []string) string {
 
 var (
 	commandsWithPathArgs = map[string]int{
-		"cat": -1,
-		"cd":  1,
-		"cp":  2,
-		"ls":  -1,
-		"mv":  2,
-		"rm":  1,
+		"cat":     -1,
+		"cd":      1,
+		"cp":      -1,
+		"edit":    1,
+		"export":  1,
+		"history": 1,
+		"ls":      -1,
+		"mv":      -1,
+		"rm":      -1,
 	}
 	commandsWithDefaultPath = map[string]bool{
 		"cat": true,
@@ -0,0 +1,296 @@
+package vc
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+// Sync operations, as shown in the plan
+const (
+	syncAdd    = '+'
+	syncUpdate = '~'
+	syncDelete = '-'
+)
+
+// SyncCommand mirrors a local directory tree and a tree of file secrets
+type SyncCommand struct {
+	baseCommand
+	fs        *flag.FlagSet
+	sub       string
+	delete    bool
+	dryRun    bool
+	preserve  bool
+	compress  bool
+	encoding  string
+	chunkSize int
+}
+
+// syncEntry is a file in the local or remote tree
+type syncEntry struct {
+	name string
+	sum  string
+	data map[string]interface{}
+}
+
+// syncAction is a planned operation on a relative path
+type syncAction struct {
+	op  rune
+	rel string
+}
+
+func (cmd *SyncCommand) Help() string {
+	return "Usage: vc file sync push [<options>] <directory> <secret path>\n" +
+		"       vc file sync pull [<options>] <secret path> <directory>\n\nOptions:\n" + defaults(cmd.fs)
+}
+
+func (cmd *SyncCommand) Run(args []string) int {
+	if err := cmd.fs.Parse(args); err != nil {
+		return SyntaxError
+	}
+	if args = cmd.fs.Args(); len(args) != 2 {
+		return Help
+	}
+	if cmd.compress && cmd.encoding != encodingGzip && cmd.encoding != encodingZstd {
+		cmd.ui.Error(fmt.Sprintf("error: unsupported encoding %q", cmd.encoding))
+		return SyntaxError
+	}
+
+	client, err := cmd.Client()
+	if err != nil {
+		cmd.ui.Error(err.Error())
+		return ClientError
+	}
+
+	dir, root := args[0], args[1]
+	if cmd.sub == "pull" {
+		dir, root = args[1], args[0]
+	}
+	root = client.abspath(root)
+
+	local, err := cmd.localFiles(dir)
+	if err != nil {
+		cmd.ui.Error(fmt.Sprintf("error: %v", err))
+		return SystemError
+	}
+	remote, err := cmd.remoteFiles(client, root)
+	if err != nil {
+		cmd.ui.Error(fmt.Sprintf("error: %v", err))
+		return ServerError
+	}
+
+	var (
+		src, dst = local, remote
+		summary  treeSummary
+	)
+	if cmd.sub == "pull" {
+		src, dst = remote, local
+	}
+	plan := syncPlan(src, dst, cmd.delete)
+	for _, action := range plan {
+		cmd.ui.Output(fmt.Sprintf("%c %s", action.op, action.rel))
+	}
+	if cmd.dryRun {
+		return Success
+	}
+
+	// Unchanged files are skipped
+	summary.skipped = len(src)
+	for _, action := range plan {
+		if action.op != syncDelete {
+			summary.skipped--
+		}
+		if err = cmd.apply(client, dir, root, action, local, remote); err != nil {
+			cmd.ui.Error(fmt.Sprintf("%s: %v", action.rel, err))
+			summary.failed++
+		} else {
+			summary.done++
+		}
+	}
+
+	if cmd.sub == "push" {
+		cmd.ui.Info("pushed: " + summary.String())
+	} else {
+		cmd.ui.Info("pulled: " + summary.String())
+	}
+	if summary.failed > 0 {
+		return ServerError
+	}
+	return Success
+}
+
+// apply a planned action
+func (cmd *SyncCommand) apply(client *Client, dir, root string, action syncAction, local, remote map[string]syncEntry) error {
+	var (
+		name   = filepath.Join(dir, filepath.FromSlash(action.rel))
+		secret = path.Join(root, action.rel)
+	)
+	switch {
+	case cmd.sub == "push" && action.op == syncDelete:
+		return client.deleteFile(secret, remote[action.rel].data)
+
+	case cmd.sub == "push":
+		stat, err := os.Stat(name)
+		if err != nil {
+			return err
+		}
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		var encoding string
+		if cmd.compress {
+			encoding = cmd.encoding
+		}
+		return client.writeFile(secret, b, localFileInfo(stat), encoding, cmd.chunkSize)
+
+	case action.op == syncDelete:
+		return os.Remove(name)
+
+	default:
+		data := remote[action.rel].data
+		b, err := client.readFile(secret, data)
+		if err != nil {
+			return err
+		}
+		if err = os.MkdirAll(filepath.Dir(name), 0700); err != nil {
+			return err
+		}
+		if err = ioutil.WriteFile(name, b, 0600); err != nil || !cmd.preserve {
+			return err
+		}
+		return newFileInfo(data).restore(name)
+	}
+}
+
+// localFiles returns the regular files below dir, keyed by their relative
+// slash separated path
+func (cmd *SyncCommand) localFiles(dir string) (map[string]syncEntry, error) {
+	files := make(map[string]syncEntry)
+	if _, err := os.Stat(dir); os.IsNotExist(err) && cmd.sub == "pull" {
+		return files, nil
+	}
+
+	err := filepath.Walk(dir, func(name string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			if !info.IsDir() {
+				Debugf("sync: skip %s: not a regular file", name)
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, name)
+		if err != nil {
+			return err
+		}
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = syncEntry{name: name, sum: sha256Hex(b)}
+		return nil
+	})
+	return files, err
+}
+
+// remoteFiles returns the file secrets below root, keyed by their relative
+// path; chunks and secrets of other types are skipped
+func (cmd *SyncCommand) remoteFiles(client *Client, root string) (map[string]syncEntry, error) {
+	files := make(map[string]syncEntry)
+	err := client.Walk(root, func(name string, err error) error {
+		if name == root && os.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(name, root), "/")
+		if rel == "" || isChunkPath(rel) {
+			return nil
+		}
+		secret, err := client.Read(name)
+		if err != nil {
+			return err
+		} else if secret == nil {
+			return nil
+		}
+		if kind, _ := secret.Data[CodecTypeKey].(string); kind != "file" {
+			Debugf("sync: skip %s: not a file", name)
+			return nil
+		}
+		sum, _ := secret.Data[fileSHA256Key].(string)
+		if sum == "" {
+			// Files stored by older versions have no checksum
+			if b, err := client.readFile(name, secret.Data); err == nil {
+				sum = sha256Hex(b)
+			}
+		}
+		files[rel] = syncEntry{name: name, sum: sum, data: secret.Data}
+		return nil
+	})
+	return files, err
+}
+
+// syncPlan returns the actions to mirror src to dst, sorted by path
+func syncPlan(src, dst map[string]syncEntry, delete bool) (plan []syncAction) {
+	for rel, entry := range src {
+		if other, ok := dst[rel]; !ok {
+			plan = append(plan, syncAction{syncAdd, rel})
+		} else if other.sum != entry.sum {
+			plan = append(plan, syncAction{syncUpdate, rel})
+		}
+	}
+	if delete {
+		for rel := range dst {
+			if _, ok := src[rel]; !ok {
+				plan = append(plan, syncAction{syncDelete, rel})
+			}
+		}
+	}
+	sort.Slice(plan, func(i, j int) bool {
+		return plan[i].rel < plan[j].rel
+	})
+	return
+}
+
+func (cmd *SyncCommand) Synopsis() string {
+	if cmd.sub == "pull" {
+		return "mirror a tree of file secrets to a directory"
+	}
+	return "mirror a directory to a tree of file secrets"
+}
+
+func SyncCommandFactory(ui cli.Ui, sub string) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		cmd := &SyncCommand{
+			sub: sub,
+			baseCommand: baseCommand{
+				ui: ui,
+			},
+		}
+
+		cmd.fs = flag.NewFlagSet("file sync "+sub, flag.ContinueOnError)
+		cmd.fs.BoolVar(&cmd.delete, "delete", false, "remove extraneous files from the destination")
+		cmd.fs.BoolVar(&cmd.dryRun, "n", false, "dry run, only show the plan")
+		if sub == "push" {
+			cmd.fs.BoolVar(&cmd.compress, "z", false, "compress the files")
+			cmd.fs.StringVar(&cmd.encoding, "encoding", encodingGzip, "compression used by -z: "+encodingGzip+" or "+encodingZstd)
+			cmd.fs.IntVar(&cmd.chunkSize, "chunk-size", fileChunkSize, "files larger than this many bytes are stored in chunks")
+		} else {
+			cmd.fs.BoolVar(&cmd.preserve, "p", true, "restore the stored mode, ownership and modification time")
+		}
+		cmd.fs.Usage = func() {
+			fmt.Print(cmd.Help())
+		}
+
+		return cmd, nil
+	}
+}
@@ -0,0 +1,136 @@
+package vc
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func testSyncFactory(sub string) func(cli.Ui) cli.CommandFactory {
+	return func(ui cli.Ui) cli.CommandFactory {
+		return SyncCommandFactory(ui, sub)
+	}
+}
+
+func TestSyncPlan(t *testing.T) {
+	src := map[string]syncEntry{
+		"same":    {sum: "1"},
+		"changed": {sum: "2"},
+		"added":   {sum: "3"},
+	}
+	dst := map[string]syncEntry{
+		"same":    {sum: "1"},
+		"changed": {sum: "0"},
+		"removed": {sum: "4"},
+	}
+
+	want := []syncAction{{syncAdd, "added"}, {syncUpdate, "changed"}}
+	if got := syncPlan(src, dst, false); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	want = append(want, syncAction{syncDelete, "removed"})
+	if got := syncPlan(src, dst, true); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestSyncCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vc-test")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		local  = filepath.Join(dir, "local")
+		pulled = filepath.Join(dir, "pulled")
+	)
+	for name, contents := range map[string]string{
+		"app.conf":      "listen = 8080\n",
+		"tls/app.pem":   "certificate\n",
+		"tls/large.key": "larger than a chunk\n",
+	} {
+		name = filepath.Join(local, filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(name), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(name, []byte(contents), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, client := testMemoryClient()
+	if err = client.writeFile("kv/sync/extra", []byte("extra"), nil, "", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []testCommand{
+		testCommand{
+			Factory: testSyncFactory("push"),
+			Args:    []string{"--help"},
+			Code:    Success,
+		},
+		testCommand{
+			Factory: testSyncFactory("push"),
+			Args:    []string{"-n", local, "kv/sync"},
+			Code:    Success,
+			Client:  client,
+		},
+		testCommand{
+			Factory: testSyncFactory("push"),
+			Args:    []string{"-chunk-size", "8", "-z", "--delete", local, "kv/sync"},
+			Code:    Success,
+			Client:  client,
+		},
+		testCommand{
+			Factory: testSyncFactory("pull"),
+			Args:    []string{"kv/sync", pulled},
+			Code:    Success,
+			Client:  client,
+		},
+	} {
+		testCommandRun(t, test)
+	}
+
+	if secret, err := client.Read("kv/sync/extra"); err != nil {
+		t.Fatal(err)
+	} else if secret != nil {
+		t.Fatalf("expected extraneous secret to be removed, got %+v", secret)
+	}
+
+	// Chunks are not part of the mirrored tree
+	remote, err := (&SyncCommand{}).remoteFiles(client, "/kv/sync")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remote) != 3 {
+		t.Fatalf("expected 3 remote files, got %+v", remote)
+	}
+
+	for _, name := range []string{"app.conf", "tls/app.pem", "tls/large.key"} {
+		want, err := ioutil.ReadFile(filepath.Join(local, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ioutil.ReadFile(filepath.Join(pulled, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(want) {
+			t.Fatalf("%s: expected %q, got %q", name, want, got)
+		}
+	}
+
+	// Everything is in sync
+	src, err := (&SyncCommand{sub: "pull"}).localFiles(pulled)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan := syncPlan(remote, src, true); len(plan) != 0 {
+		t.Fatalf("expected no changes, got %q", plan)
+	}
+}
@@ -71,6 +71,10 @@ func (cmd *TemplateCommand) Run(args []string) int {
 		cmd.ui.Error("error: " + err.Error())
 		return 1
 	}
+	if err = cmd.Close(); err != nil {
+		cmd.ui.Error("error: " + err.Error())
+		return 1
+	}
 
 	return 0
 }
@@ -137,7 +141,7 @@ func (cmd *TemplateCommand) executeTemplateDecodes(client *Client, input string)
 
 	for path, k := range cmd.decode {
 		var secret *api.Secret
-		if secret, err = client.Logical().Read(path); err != nil {
+		if secret, err = client.Read(path); err != nil {
 			return "", err
 		}
 		if secret == nil || secret.Data == nil {
@@ -172,7 +176,7 @@ func (cmd *TemplateCommand) executeTemplateSecrets(client *Client, input string)
 	// For each of the secret paths, lookup the secret
 	for path, kv := range cmd.lookup {
 		var secret *api.Secret
-		if secret, err = client.Logical().Read(strings.TrimLeft(path, "/")); err != nil {
+		if secret, err = client.Read(path); err != nil {
 			return "", err
 		}
 		if secret == nil {
@@ -200,7 +204,7 @@ func (cmd *TemplateCommand) executeTemplateNested(client *Client, input string)
 	// For each of the secret paths, lookup the secret
 	for path, kv := range cmd.lookup {
 		var secret *api.Secret
-		if secret, err = client.Logical().Read(strings.TrimLeft(path, "/")); err != nil {
+		if secret, err = client.Read(path); err != nil {
 			return "", err
 		}
 		if secret == nil {
@@ -296,6 +300,7 @@ func TemplateCommandFactory(ui cli.Ui) cli.CommandFactory {
 		cmd.fs = flag.NewFlagSet("template", flag.ContinueOnError)
 		cmd.fs.StringVar(&cmd.mod, "m", "0600", "output mode")
 		cmd.fs.StringVar(&cmd.out, "o", "", "output (default: stdout)")
+		cmd.encryptFlags(cmd.fs)
 		cmd.fs.StringVar(&cmd.templatingMode, "t", "html", "templating mode: html or text")
 		cmd.fs.StringVar(&cmd.user, "u", "", "output file user name or numeric user id (default: current user)")
 		cmd.fs.StringVar(&cmd.group, "g", "", "output file group name or numeric group id (default: current group)")
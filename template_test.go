@@ -2,19 +2,18 @@ package vc
 
 import (
 	"bytes"
-	"github.com/hashicorp/vault/api"
-	"github.com/hashicorp/vault/http"
-	"github.com/hashicorp/vault/vault"
-	"github.com/mitchellh/cli"
 	"io/ioutil"
-	"net"
+	"net/http/httptest"
 	"os"
 	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
 )
 
 func TestTemplateCommand_Run(t *testing.T) {
 
-	_, vaultClient := createTestVault(t)
+	vaultClient := createTestVault(t)
 	writeSecret(t, vaultClient, "secret/foo/bar", map[string]interface{}{
 		"secret": "bar",
 	})
@@ -34,7 +33,7 @@ func TestTemplateCommand_Run(t *testing.T) {
 }
 
 func TestTemplateCommand_EscapeXml(t *testing.T) {
-	_, vaultClient := createTestVault(t)
+	vaultClient := createTestVault(t)
 	writeSecret(t, vaultClient, "secret/foo/bar", map[string]interface{}{
 		"secret": "bar",
 	})
@@ -64,7 +63,7 @@ func TestTemplateCommand_EscapeXml(t *testing.T) {
 }
 
 func TestTemplateCommand_TextTemplateXml(t *testing.T) {
-	_, vaultClient := createTestVault(t)
+	vaultClient := createTestVault(t)
 	writeSecret(t, vaultClient, "secret/foo/bar", map[string]interface{}{
 		"secret": "bar",
 	})
@@ -141,28 +140,24 @@ func createCommandUnderTest(t *testing.T, client *api.Client) (*TemplateCommand,
 	return templateCommand, b
 }
 
-func createTestVault(t *testing.T) (net.Listener, *api.Client) {
+func createTestVault(t *testing.T) *api.Client {
 	t.Helper()
 
-	// Create an in-memory, unsealed core (the "backend", if you will).
-	core, keyShares, rootToken := vault.TestCoreUnsealed(t)
-	_ = keyShares
-
-	// Start an HTTP server for the core.
-	listener, addr := http.TestServer(t, core)
+	// Start an HTTP server for an in-memory Vault.
+	server := httptest.NewServer(NewMemoryBackend())
+	t.Cleanup(server.Close)
 
-	// Create a client that talks to the server, initially authenticating with
-	// the root token.
+	// Create a client that talks to the server.
 	conf := api.DefaultConfig()
-	conf.Address = addr
+	conf.Address = server.URL
 
 	client, err := api.NewClient(conf)
 	if err != nil {
 		t.Fatal(err)
 	}
-	client.SetToken(rootToken)
+	client.SetToken("test")
 
-	return listener, client
+	return client
 }
 
 type byteBufferWriteCloser struct {
@@ -0,0 +1,267 @@
+package vc
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+// TreeCommand displays the secret hierarchy as a tree
+type TreeCommand struct {
+	baseCommand
+	fs       *flag.FlagSet
+	level    int
+	dirsOnly bool
+	keys     bool
+	types    bool
+	ascii    bool
+	json     bool
+}
+
+// treeNode is a directory or secret in the tree
+type treeNode struct {
+	Name     string      `json:"name"`
+	Path     string      `json:"path"`
+	Type     string      `json:"type"`
+	Mount    string      `json:"mount,omitempty"`
+	Kind     string      `json:"kind,omitempty"`
+	Keys     *int        `json:"keys,omitempty"`
+	Error    string      `json:"error,omitempty"`
+	Children []*treeNode `json:"children,omitempty"`
+}
+
+// Node types
+const (
+	treeDirectory = "directory"
+	treeSecret    = "secret"
+)
+
+// treeLines are the branch drawing characters: branch, last branch, line and
+// indent
+var (
+	treeLinesUnicode = [4]string{"├── ", "└── ", "│   ", "    "}
+	treeLinesASCII   = [4]string{"|-- ", "`-- ", "|   ", "    "}
+)
+
+func (cmd *TreeCommand) Help() string {
+	return "Usage: vc tree [<options>] [<secret path>] [... <secret path>]\n\nOptions:\n" + defaults(cmd.fs)
+}
+
+func (cmd *TreeCommand) Run(args []string) int {
+	args, err := parseInterspersed(cmd.fs, args)
+	if err != nil {
+		return SyntaxError
+	}
+	if cmd.level < 0 {
+		cmd.ui.Error(fmt.Sprintf("error: invalid level %d", cmd.level))
+		return SyntaxError
+	}
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+
+	client, err := cmd.Client()
+	if err != nil {
+		cmd.ui.Error(err.Error())
+		return ClientError
+	}
+
+	var (
+		ret   int
+		roots []*treeNode
+	)
+	for _, root := range args {
+		root = client.abspath(root)
+		if _, err := client.Stat(root); err != nil {
+			cmd.ui.Error(fmt.Sprintf("%s: %v", root, err))
+			ret = ServerError
+			continue
+		}
+
+		node := &treeNode{Name: root, Path: root, Type: treeDirectory}
+		if code := cmd.build(client, node, 1); code > ret {
+			ret = code
+		}
+		roots = append(roots, node)
+	}
+
+	if cmd.json {
+		if roots == nil {
+			roots = []*treeNode{}
+		}
+		b, err := json.MarshalIndent(roots, "", "  ")
+		if err != nil {
+			cmd.ui.Error(err.Error())
+			return CodecError
+		}
+		cmd.ui.Output(string(b))
+		return ret
+	}
+
+	var (
+		buf         = new(bytes.Buffer)
+		dirs, files int
+	)
+	for _, node := range roots {
+		fmt.Fprintln(buf, node.Name)
+		d, f := cmd.print(buf, node.Children, "")
+		dirs, files = dirs+d, files+f
+	}
+	if cmd.dirsOnly {
+		fmt.Fprintf(buf, "\n%d %s\n", dirs, plural(dirs, "directory", "directories"))
+	} else {
+		fmt.Fprintf(buf, "\n%d %s, %d %s\n",
+			dirs, plural(dirs, "directory", "directories"),
+			files, plural(files, "secret", "secrets"))
+	}
+	cmd.ui.Output(strings.TrimRight(buf.String(), "\n"))
+	return ret
+}
+
+// build adds the contents of the directory to node, descending until the
+// level limit is reached; directories that can't be listed are reported and
+// skipped
+func (cmd *TreeCommand) build(client *Client, node *treeNode, depth int) int {
+	infos, err := client.ReadDir(node.Path)
+	if err != nil {
+		cmd.ui.Error(fmt.Sprintf("%s: %v", node.Path, err))
+		node.Error = err.Error()
+		return ServerError
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Name() == infos[j].Name() {
+			// A secret may also be a directory, list the secret first
+			return !infos[i].IsDir()
+		}
+		return infos[i].Name() < infos[j].Name()
+	})
+
+	var ret int
+	for _, info := range infos {
+		name := path.Base(info.Name())
+		if isChunkPath(name) || (cmd.dirsOnly && !info.IsDir()) {
+			continue
+		}
+
+		child := &treeNode{Name: name, Path: info.Name(), Type: treeSecret}
+		node.Children = append(node.Children, child)
+		if info.IsDir() {
+			child.Type = treeDirectory
+			if mount, ok := info.(*mountInfo); ok {
+				child.Mount = mountType(mount.MountOutput)
+			}
+			if cmd.level == 0 || depth < cmd.level {
+				if code := cmd.build(client, child, depth+1); code > ret {
+					ret = code
+				}
+			}
+			continue
+		}
+
+		if cmd.keys || cmd.types {
+			secret, err := client.Read(child.Path)
+			if err != nil {
+				cmd.ui.Error(fmt.Sprintf("%s: %v", child.Path, err))
+				child.Error = err.Error()
+				ret = ServerError
+				continue
+			}
+			if secret == nil {
+				continue
+			}
+			if cmd.keys {
+				keys := len(secret.Data)
+				if _, ok := secret.Data[CodecTypeKey]; ok {
+					keys--
+				}
+				child.Keys = &keys
+			}
+			if cmd.types {
+				child.Kind, _ = secret.Data[CodecTypeKey].(string)
+			}
+		}
+	}
+	return ret
+}
+
+// print the nodes below prefix, returns the number of directories and secrets
+func (cmd *TreeCommand) print(w io.Writer, nodes []*treeNode, prefix string) (dirs, files int) {
+	lines := treeLinesUnicode
+	if cmd.ascii {
+		lines = treeLinesASCII
+	}
+	for i, node := range nodes {
+		branch, indent := lines[0], lines[2]
+		if i == len(nodes)-1 {
+			branch, indent = lines[1], lines[3]
+		}
+
+		name := node.Name
+		if node.Type == treeDirectory {
+			name += "/"
+			dirs++
+		} else {
+			files++
+		}
+		if cmd.types && node.Mount != "" {
+			name += " [" + node.Mount + "]"
+		}
+		if node.Kind != "" {
+			name += " [" + node.Kind + "]"
+		}
+		if node.Keys != nil {
+			name += " (" + strconv.Itoa(*node.Keys) + " " + plural(*node.Keys, "key", "keys") + ")"
+		}
+		if node.Error != "" {
+			name += " [error: " + node.Error + "]"
+		}
+		fmt.Fprintln(w, prefix+branch+name)
+
+		d, f := cmd.print(w, node.Children, prefix+indent)
+		dirs, files = dirs+d, files+f
+	}
+	return
+}
+
+// plural returns one if n is 1, many otherwise
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+func (cmd *TreeCommand) Synopsis() string {
+	return "list secrets in a tree"
+}
+
+func TreeCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		cmd := &TreeCommand{
+			baseCommand: baseCommand{
+				ui: ui,
+			},
+		}
+
+		cmd.fs = flag.NewFlagSet("tree", flag.ContinueOnError)
+		cmd.fs.IntVar(&cmd.level, "L", 0, "descend at most level directories deep (default: unlimited)")
+		cmd.fs.BoolVar(&cmd.dirsOnly, "d", false, "list directories only")
+		cmd.fs.BoolVar(&cmd.keys, "k", false, "show the number of keys of secrets")
+		cmd.fs.BoolVar(&cmd.types, "t", false, "show the "+CodecTypeKey+" of secrets and the type of mounts")
+		cmd.fs.BoolVar(&cmd.ascii, "ascii", false, "draw the tree with ASCII characters")
+		cmd.fs.BoolVar(&cmd.json, "json", false, "output the tree as JSON")
+		cmd.fs.Usage = func() {
+			fmt.Print(cmd.Help())
+		}
+
+		return cmd, nil
+	}
+}
@@ -0,0 +1,99 @@
+package vc
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestTreeCommand(t *testing.T) {
+	_, client := testMemoryClient()
+	testWrite(t, client, "secret/app/db", map[string]interface{}{"user": "admin", "password": "secret"})
+	testWrite(t, client, "secret/app/tls/server.pem", map[string]interface{}{CodecTypeKey: "pem", "certificate": "-"})
+	testWrite(t, client, "secret/app/tls/deep/ca.pem", map[string]interface{}{CodecTypeKey: "pem"})
+	if err := client.writeFile("secret/app/conf", []byte("listen = 80\n"), nil, encodingGzip, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"secret/app"}, `/secret/app
+├── conf
+├── db
+└── tls/
+    ├── deep/
+    │   └── ca.pem
+    └── server.pem
+
+2 directories, 4 secrets
+`},
+		{[]string{"-ascii", "-L", "1", "-k", "-t", "secret/app"}, `/secret/app
+|-- conf [file] (4 keys)
+|-- db (2 keys)
+` + "`-- tls/" + `
+
+1 directory, 2 secrets
+`},
+		{[]string{"-d", "secret/app"}, `/secret/app
+└── tls/
+    └── deep/
+
+2 directories
+`},
+		{[]string{"-d", "-L", "1", "-t", "/"}, `/
+├── kv/ [kv2]
+└── secret/ [kv]
+
+2 directories
+`},
+	} {
+		ui := cli.NewMockUi()
+		cmd := mustTreeCommand(t, ui)
+		cmd.setClient(client)
+		if code := cmd.Run(test.args); code != Success {
+			t.Fatalf("%v: expected return code %d, got %d: %s", test.args, Success, code, ui.ErrorWriter)
+		}
+		if got := ui.OutputWriter.String(); got != test.want {
+			t.Fatalf("%v: expected output:\n%s\ngot:\n%s", test.args, test.want, got)
+		}
+	}
+
+	ui := cli.NewMockUi()
+	cmd := mustTreeCommand(t, ui)
+	cmd.setClient(client)
+	if code := cmd.Run([]string{"-json", "-t", "secret/app/tls"}); code != Success {
+		t.Fatalf("expected return code %d, got %d: %s", Success, code, ui.ErrorWriter)
+	}
+	var roots []*treeNode
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &roots); err != nil {
+		t.Fatal(err)
+	}
+	if len(roots) != 1 || len(roots[0].Children) != 2 {
+		t.Fatalf("unexpected tree %s", ui.OutputWriter)
+	}
+	if deep := roots[0].Children[0]; deep.Type != treeDirectory || deep.Path != "/secret/app/tls/deep" || len(deep.Children) != 1 {
+		t.Fatalf("unexpected directory %+v", deep)
+	}
+	if pem := roots[0].Children[1]; pem.Type != treeSecret || pem.Kind != "pem" || pem.Keys != nil {
+		t.Fatalf("unexpected secret %+v", pem)
+	}
+
+	testCommandRun(t, testCommand{
+		Factory: TreeCommandFactory,
+		Args:    []string{"secret/missing"},
+		Code:    ServerError,
+		Client:  client,
+	})
+}
+
+func mustTreeCommand(t *testing.T, ui cli.Ui) *TreeCommand {
+	t.Helper()
+	cmd, err := TreeCommandFactory(ui)()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cmd.(*TreeCommand)
+}
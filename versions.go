@@ -0,0 +1,184 @@
+package vc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// ErrNotVersioned is returned for version operations on secrets that do not
+// live on a KV version 2 mount
+var ErrNotVersioned = errors.New("vc: secret is not on a KV version 2 mount")
+
+// SecretVersion describes a single version of a KV version 2 secret
+type SecretVersion struct {
+	Version      int
+	CreatedTime  time.Time
+	DeletionTime time.Time
+	Destroyed    bool
+}
+
+// Deleted returns true if the version was (soft) deleted
+func (v SecretVersion) Deleted() bool {
+	return !v.DeletionTime.IsZero()
+}
+
+// SecretMetadata is the metadata Vault keeps for KV version 2 secrets
+type SecretMetadata struct {
+	CurrentVersion int
+	OldestVersion  int
+	CreatedTime    time.Time
+	UpdatedTime    time.Time
+
+	// Versions sorted by version number
+	Versions []SecretVersion
+}
+
+// Version looks up the metadata of a version
+func (m *SecretMetadata) Version(version int) (SecretVersion, bool) {
+	for _, v := range m.Versions {
+		if v.Version == version {
+			return v, true
+		}
+	}
+	return SecretVersion{}, false
+}
+
+// ReadVersion reads a specific version of a secret
+func (c *Client) ReadVersion(path string, version int) (*api.Secret, error) {
+	path, kv, err := c.kvPath(path, "data")
+	if err != nil {
+		return nil, err
+	} else if kv != 2 {
+		return nil, ErrNotVersioned
+	}
+
+	secret, err := c.backend().ReadWithData(path, map[string][]string{
+		"version": []string{strconv.Itoa(version)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return kvUnwrap(secret), nil
+}
+
+// Metadata reads the metadata of a secret; returns nil if there is no secret
+func (c *Client) Metadata(path string) (*SecretMetadata, error) {
+	path, kv, err := c.kvPath(path, "metadata")
+	if err != nil {
+		return nil, err
+	} else if kv != 2 {
+		return nil, ErrNotVersioned
+	}
+
+	secret, err := c.backend().Read(path)
+	if err != nil || secret == nil || secret.Data == nil {
+		return nil, err
+	}
+
+	meta := &SecretMetadata{
+		CurrentVersion: toInt(secret.Data["current_version"]),
+		OldestVersion:  toInt(secret.Data["oldest_version"]),
+		CreatedTime:    toTime(secret.Data["created_time"]),
+		UpdatedTime:    toTime(secret.Data["updated_time"]),
+	}
+	if versions, ok := secret.Data["versions"].(map[string]interface{}); ok {
+		for key, value := range versions {
+			version, err := strconv.Atoi(key)
+			if err != nil {
+				return nil, fmt.Errorf("vc: %s: invalid version %q", path, key)
+			}
+			data, _ := value.(map[string]interface{})
+			destroyed, _ := data["destroyed"].(bool)
+			meta.Versions = append(meta.Versions, SecretVersion{
+				Version:      version,
+				CreatedTime:  toTime(data["created_time"]),
+				DeletionTime: toTime(data["deletion_time"]),
+				Destroyed:    destroyed,
+			})
+		}
+	}
+	sort.Slice(meta.Versions, func(i, j int) bool {
+		return meta.Versions[i].Version < meta.Versions[j].Version
+	})
+
+	return meta, nil
+}
+
+// DeleteVersions (soft) deletes versions of a secret
+func (c *Client) DeleteVersions(path string, versions []int) error {
+	return c.kvVersions("delete", path, versions)
+}
+
+// Undelete restores deleted versions of a secret
+func (c *Client) Undelete(path string, versions []int) error {
+	return c.kvVersions("undelete", path, versions)
+}
+
+// Destroy permanently removes the data of versions of a secret
+func (c *Client) Destroy(path string, versions []int) error {
+	return c.kvVersions("destroy", path, versions)
+}
+
+// kvVersions calls one of the KV version 2 endpoints operating on versions
+func (c *Client) kvVersions(prefix, path string, versions []int) error {
+	path, kv, err := c.kvPath(path, prefix)
+	if err != nil {
+		return err
+	} else if kv != 2 {
+		return ErrNotVersioned
+	}
+
+	Debugf("kv: %s versions %v of %q", prefix, versions, path)
+	_, err = c.backend().Write(path, map[string]interface{}{
+		"versions": versions,
+	})
+	return err
+}
+
+// parseVersions parses a comma separated list of version numbers
+func parseVersions(s string) (versions []int, err error) {
+	if s == "" {
+		return
+	}
+	for _, field := range strings.Split(s, ",") {
+		var version int
+		if version, err = strconv.Atoi(strings.TrimSpace(field)); err != nil || version < 1 {
+			return nil, fmt.Errorf("invalid version %q", field)
+		}
+		versions = append(versions, version)
+	}
+	return
+}
+
+// toInt converts a decoded JSON number to int
+func toInt(v interface{}) int {
+	switch v := v.(type) {
+	case json.Number:
+		i, _ := v.Int64()
+		return int(i)
+	case float64:
+		return int(v)
+	case int:
+		return v
+	case int64:
+		return int(v)
+	}
+	return 0
+}
+
+// toTime converts a decoded RFC3339 time stamp to time.Time
+func toTime(v interface{}) time.Time {
+	if s, ok := v.(string); ok && s != "" {
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,44 @@
+package vc
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseVersions(t *testing.T) {
+	tests := []struct {
+		Test string
+		Want []int
+		Fail bool
+	}{
+		{"", nil, false},
+		{"1", []int{1}, false},
+		{"1,2, 3", []int{1, 2, 3}, false},
+		{"0", nil, true},
+		{"1,two", nil, true},
+	}
+	for _, test := range tests {
+		versions, err := parseVersions(test.Test)
+		if test.Fail {
+			if err == nil {
+				t.Fatalf("parseVersions(%q): expected error", test.Test)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("parseVersions(%q): %v", test.Test, err)
+		}
+		if !reflect.DeepEqual(versions, test.Want) {
+			t.Fatalf("parseVersions(%q): expected %v, got %v", test.Test, test.Want, versions)
+		}
+	}
+}
+
+func TestToInt(t *testing.T) {
+	for _, v := range []interface{}{json.Number("42"), float64(42), 42, int64(42)} {
+		if i := toInt(v); i != 42 {
+			t.Fatalf("toInt(%#v): expected 42, got %d", v, i)
+		}
+	}
+}
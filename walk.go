@@ -0,0 +1,152 @@
+package vc
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// walkParallel is the maximum number of directories listed concurrently
+const walkParallel = 8
+
+// SkipDir can be returned by a walk function to skip the directory it was
+// called for, or the remaining items in the directory if called for a secret
+var SkipDir = filepath.SkipDir
+
+// WalkDirFunc is called by WalkDir for every directory and secret visited. If
+// listing a directory fails, the function is called a second time for that
+// directory with the error; returning nil continues the walk.
+type WalkDirFunc func(path string, info os.FileInfo, err error) error
+
+// WalkFunc is called by Walk for every secret visited, and for directories
+// that could not be listed with the error.
+type WalkFunc func(path string, err error) error
+
+// Walk calls fn for all secrets in the tree rooted at root, in lexical order.
+func (c *Client) Walk(root string, fn WalkFunc) error {
+	return c.WalkDir(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return fn(path, err)
+		}
+		if info.IsDir() {
+			return nil
+		}
+		return fn(path, nil)
+	})
+}
+
+// WalkDir calls fn for all directories and secrets in the tree rooted at root,
+// in lexical order, including root itself. Directories are listed ahead of
+// the traversal, at most walkParallel at a time, but fn is always called from
+// the calling goroutine.
+func (c *Client) WalkDir(root string, fn WalkDirFunc) error {
+	root = c.abspath(root)
+
+	info, err := c.Stat(root)
+	if err != nil {
+		return fn(root, nil, err)
+	}
+
+	w := &walker{
+		client: c,
+		fn:     fn,
+		sem:    make(chan struct{}, walkParallel),
+	}
+
+	if !info.IsDir() {
+		if err = fn(root, info, nil); err != nil {
+			if err == SkipDir {
+				return nil
+			}
+			return err
+		}
+
+		// A secret may also be a directory in Vault
+		infos, err := c.ReadDir(root)
+		if err != nil || len(infos) == 0 {
+			return nil
+		}
+		info = &secretInfo{Path: root, Key: path.Base(root) + "/"}
+		return w.walk(root, info, w.done(infos))
+	}
+
+	err = w.walk(root, info, w.list(root))
+	if err == SkipDir {
+		return nil
+	}
+	return err
+}
+
+// walker implements the traversal of WalkDir
+type walker struct {
+	client *Client
+	fn     WalkDirFunc
+	sem    chan struct{}
+}
+
+// listing is the pending result of a directory listing
+type listing chan listingResult
+
+type listingResult struct {
+	infos []os.FileInfo
+	err   error
+}
+
+// list starts listing dir in the background
+func (w *walker) list(dir string) listing {
+	result := make(listing, 1)
+	go func() {
+		w.sem <- struct{}{}
+		infos, err := w.client.ReadDir(dir)
+		<-w.sem
+		result <- listingResult{infos, err}
+	}()
+	return result
+}
+
+// done returns an already completed listing
+func (w *walker) done(infos []os.FileInfo) listing {
+	result := make(listing, 1)
+	result <- listingResult{infos: infos}
+	return result
+}
+
+func (w *walker) walk(dir string, info os.FileInfo, pending listing) error {
+	if err := w.fn(dir, info, nil); err != nil {
+		return err
+	}
+
+	result := <-pending
+	if result.err != nil {
+		return w.fn(dir, info, result.err)
+	}
+
+	infos := result.infos
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name() < infos[j].Name()
+	})
+
+	// Start listing the subdirectories, while we visit them in order
+	listings := make([]listing, len(infos))
+	for i, item := range infos {
+		if item.IsDir() {
+			listings[i] = w.list(item.Name())
+		}
+	}
+
+	for i, item := range infos {
+		name := strings.TrimRight(item.Name(), "/")
+		if !item.IsDir() {
+			if err := w.fn(name, item, nil); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := w.walk(name, item, listings[i]); err != nil && err != SkipDir {
+			return err
+		}
+	}
+	return nil
+}
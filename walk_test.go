@@ -0,0 +1,154 @@
+package vc
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// failingBackend fails to list paths containing "broken"
+type failingBackend struct {
+	*MemoryBackend
+}
+
+func (b failingBackend) List(path string) (*api.Secret, error) {
+	if strings.Contains(path, "broken") {
+		return nil, errors.New("list failed")
+	}
+	return b.MemoryBackend.List(path)
+}
+
+func testWalkClient(t *testing.T) *Client {
+	t.Helper()
+	c := NewBackendClient(failingBackend{testBackend()})
+	for _, path := range []string{
+		"secret/app",
+		"secret/app/db",
+		"secret/app/prod/api",
+		"secret/app/prod/db",
+		"secret/app/staging/db",
+		"secret/broken/db",
+		"secret/top",
+	} {
+		testWrite(t, c, path, map[string]interface{}{"key": "test"})
+	}
+	return c
+}
+
+func TestClientWalkDir(t *testing.T) {
+	c := testWalkClient(t)
+
+	var visited, failed []string
+	err := c.WalkDir("secret", func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			failed = append(failed, path)
+			return nil
+		}
+		if info.IsDir() {
+			path += "/"
+		}
+		if path == "/secret/app/staging/" {
+			return SkipDir
+		}
+		visited = append(visited, path)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"/secret/",
+		"/secret/app",
+		"/secret/app/",
+		"/secret/app/db",
+		"/secret/app/prod/",
+		"/secret/app/prod/api",
+		"/secret/app/prod/db",
+		"/secret/broken/",
+		"/secret/top",
+	}
+	if !reflect.DeepEqual(visited, want) {
+		t.Fatalf("expected %q, got %q", want, visited)
+	}
+	if want := []string{"/secret/broken"}; !reflect.DeepEqual(failed, want) {
+		t.Fatalf("expected errors for %q, got %q", want, failed)
+	}
+}
+
+func TestClientWalk(t *testing.T) {
+	c := testWalkClient(t)
+
+	var visited []string
+	err := c.Walk("secret/app", func(path string, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == "/secret/app/prod/api" {
+			return SkipDir
+		}
+		visited = append(visited, path)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"/secret/app",
+		"/secret/app/db",
+		"/secret/app/staging/db",
+	}
+	if !reflect.DeepEqual(visited, want) {
+		t.Fatalf("expected %q, got %q", want, visited)
+	}
+
+	if err = c.Walk("secret", func(path string, err error) error { return err }); err == nil {
+		t.Fatal("expected error listing secret/broken")
+	}
+}
+
+// TestClientWalkMountRefresh refreshes the mounts on every lookup during
+// parallel walks, run with -race
+func TestClientWalkMountRefresh(t *testing.T) {
+	defer func(refresh time.Duration) { mountRefresh = refresh }(mountRefresh)
+	mountRefresh = 0
+
+	c := NewBackendClient(testBackend())
+	for i := 0; i < 32; i++ {
+		testWrite(t, c, fmt.Sprintf("kv/app/%02d/db", i), map[string]interface{}{"key": "test"})
+	}
+
+	var (
+		wg     sync.WaitGroup
+		counts = make([]int, 4)
+		errs   = make([]error, len(counts))
+	)
+	for i := range counts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = c.Walk("kv/app", func(path string, err error) error {
+				counts[i]++
+				return err
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	for i, count := range counts {
+		if errs[i] != nil {
+			t.Fatal(errs[i])
+		}
+		if count != 32 {
+			t.Fatalf("expected 32 secrets, got %d", count)
+		}
+	}
+}